@@ -0,0 +1,159 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package docker implements containerruntime.Runtime by delegating to a
+// dockerclient.Client, so the local platform can drive either docker or podman
+// through the same interface instead of hard-wiring dockerclient everywhere
+package docker
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/common"
+	"github.com/nuclio/nuclio/pkg/containerruntime"
+	"github.com/nuclio/nuclio/pkg/dockerclient"
+	"github.com/nuclio/nuclio/pkg/errors"
+
+	"github.com/nuclio/logger"
+)
+
+type Runtime struct {
+	logger       logger.Logger
+	dockerClient dockerclient.Client
+}
+
+// NewRuntime creates a docker-backed containerruntime.Runtime wrapping dockerClient
+func NewRuntime(parentLogger logger.Logger, dockerClient dockerclient.Client) (*Runtime, error) {
+	return &Runtime{
+		logger:       parentLogger.GetChild("docker"),
+		dockerClient: dockerClient,
+	}, nil
+}
+
+func (r *Runtime) Run(options *containerruntime.RunOptions) (string, error) {
+	containerID, err := r.dockerClient.RunContainer(options.Image, &dockerclient.RunOptions{
+		ContainerName: options.ContainerName,
+		Ports:         options.Ports,
+		Env:           options.Env,
+		Labels:        options.Labels,
+		Volumes:       options.Volumes,
+		Network:       options.Network,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to run docker container")
+	}
+
+	return containerID, nil
+}
+
+func (r *Runtime) Remove(containerID string) error {
+	return r.dockerClient.RemoveContainer(containerID)
+}
+
+func (r *Runtime) Load(archivePath string) error {
+	return r.dockerClient.Load(archivePath)
+}
+
+func (r *Runtime) GetContainers(options *containerruntime.GetContainerOptions) ([]containerruntime.Container, error) {
+	dockerContainers, err := r.dockerClient.GetContainers(&dockerclient.GetContainerOptions{
+		Name:    options.Name,
+		Labels:  options.Labels,
+		Stopped: options.Stopped,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list docker containers")
+	}
+
+	containers := make([]containerruntime.Container, 0, len(dockerContainers))
+	for _, dockerContainer := range dockerContainers {
+
+		// flatten docker's {"8080/tcp": [{HostPort: "32768"}]} port binding shape
+		// into the host-port -> container-port map containerruntime.Container uses
+		ports := map[int]int{}
+		for containerPortProto, bindings := range dockerContainer.HostConfig.PortBindings {
+			containerPort, _ := strconv.Atoi(strings.SplitN(containerPortProto, "/", 2)[0])
+
+			for _, binding := range bindings {
+				hostPort, _ := strconv.Atoi(binding.HostPort)
+				if hostPort != 0 && containerPort != 0 {
+					ports[hostPort] = containerPort
+				}
+			}
+		}
+
+		containers = append(containers, containerruntime.Container{
+			ID:     dockerContainer.ID,
+			Name:   dockerContainer.Name,
+			Labels: dockerContainer.Labels,
+			Ports:  ports,
+		})
+	}
+
+	return containers, nil
+}
+
+func (r *Runtime) AwaitHealth(containerID string, timeout *time.Duration) error {
+	return r.dockerClient.AwaitContainerHealth(containerID, timeout)
+}
+
+func (r *Runtime) Build(options *containerruntime.BuildOptions) error {
+	return r.dockerClient.Build(&dockerclient.BuildOptions{
+		ImageName:      options.ImageName,
+		DockerfilePath: options.DockerfilePath,
+		ContextDir:     options.ContextDir,
+		BuildArgs:      options.BuildArgs,
+		NoCache:        options.NoCache,
+	})
+}
+
+func (r *Runtime) Push(imageName string) error {
+	return r.dockerClient.Push(imageName)
+}
+
+func (r *Runtime) Logs(containerID string) (string, error) {
+	return r.dockerClient.GetContainerLogs(containerID)
+}
+
+// GetDefaultInvokeIPAddresses returns docker0's default bridge address when running
+// inside a container, or an empty string outside one (preserving the platform's
+// pre-podman behavior)
+func (r *Runtime) GetDefaultInvokeIPAddresses() ([]string, error) {
+	if common.RunningInContainer() {
+		return []string{"172.17.0.1"}, nil
+	}
+
+	return []string{""}, nil
+}
+
+func (r *Runtime) ImageExists(image string) (bool, error) {
+	return r.dockerClient.ImageExists(image)
+}
+
+func (r *Runtime) Pull(image string, options *containerruntime.PullOptions) error {
+	pullOptions := &dockerclient.PullOptions{}
+	if options != nil {
+		pullOptions.Username = options.Username
+		pullOptions.Password = options.Password
+	}
+
+	return r.dockerClient.Pull(image, pullOptions)
+}
+
+func (r *Runtime) GetContainerHealthStatus(containerID string) (string, error) {
+	return r.dockerClient.GetContainerHealthStatus(containerID)
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package podman implements containerruntime.Runtime by shelling out to the podman
+// CLI, including rootless mode where there is no docker0-style bridge to fall back on
+package podman
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/cmdrunner"
+	"github.com/nuclio/nuclio/pkg/containerruntime"
+	"github.com/nuclio/nuclio/pkg/errors"
+
+	"github.com/nuclio/logger"
+)
+
+// slirp4netnsGateway is the default gateway address slirp4netns assigns inside a
+// rootless podman network namespace
+const slirp4netnsGateway = "10.0.2.2"
+
+type Runtime struct {
+	logger    logger.Logger
+	cmdRunner cmdrunner.CmdRunner
+	rootless  bool
+}
+
+// NewRuntime creates a podman-backed containerruntime.Runtime. rootless controls
+// whether GetDefaultInvokeIPAddresses returns the slirp4netns gateway ahead of
+// loopback; without it, only loopback is returned
+func NewRuntime(parentLogger logger.Logger, rootless bool) (*Runtime, error) {
+	cmdRunner, err := cmdrunner.NewShellRunner(parentLogger)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create command runner")
+	}
+
+	return &Runtime{
+		logger:    parentLogger.GetChild("podman"),
+		cmdRunner: cmdRunner,
+		rootless:  rootless,
+	}, nil
+}
+
+func (r *Runtime) Run(options *containerruntime.RunOptions) (string, error) {
+	args := []string{"run", "--detach", "--name", options.ContainerName}
+
+	for hostPort, containerPort := range options.Ports {
+		args = append(args, "--publish", fmt.Sprintf("%d:%d", hostPort, containerPort))
+	}
+
+	for name, value := range options.Env {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", name, value))
+	}
+
+	for name, value := range options.Labels {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", name, value))
+	}
+
+	for hostPath, containerPath := range options.Volumes {
+		args = append(args, "--volume", fmt.Sprintf("%s:%s", hostPath, containerPath))
+	}
+
+	if options.Network != "" {
+		args = append(args, "--network", options.Network)
+	}
+
+	args = append(args, options.Image)
+
+	result, err := r.run(args...)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to run podman container")
+	}
+
+	return strings.TrimSpace(result), nil
+}
+
+func (r *Runtime) Remove(containerID string) error {
+	_, err := r.run("rm", "--force", containerID)
+	return err
+}
+
+func (r *Runtime) Load(archivePath string) error {
+	_, err := r.run("load", "--input", archivePath)
+	return err
+}
+
+func (r *Runtime) GetContainers(options *containerruntime.GetContainerOptions) ([]containerruntime.Container, error) {
+	args := []string{"ps", "--format", "json"}
+	if options.Stopped {
+		args = append(args, "--all")
+	}
+
+	if options.Name != "" {
+		args = append(args, "--filter", fmt.Sprintf("name=%s", options.Name))
+	}
+
+	for labelName, labelValue := range options.Labels {
+		args = append(args, "--filter", fmt.Sprintf("label=%s=%s", labelName, labelValue))
+	}
+
+	result, err := r.run(args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list podman containers")
+	}
+
+	var rawContainers []struct {
+		ID     string            `json:"Id"`
+		Names  []string          `json:"Names"`
+		Labels map[string]string `json:"Labels"`
+		Ports  []struct {
+			HostPort      string `json:"host_port"`
+			ContainerPort string `json:"container_port"`
+		} `json:"Ports"`
+	}
+
+	if err := json.Unmarshal([]byte(result), &rawContainers); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse podman ps output")
+	}
+
+	var containers []containerruntime.Container
+	for _, rawContainer := range rawContainers {
+		ports := map[int]int{}
+		for _, port := range rawContainer.Ports {
+			hostPort, _ := strconv.Atoi(port.HostPort)
+			containerPort, _ := strconv.Atoi(port.ContainerPort)
+			if hostPort != 0 && containerPort != 0 {
+				ports[hostPort] = containerPort
+			}
+		}
+
+		name := ""
+		if len(rawContainer.Names) > 0 {
+			name = rawContainer.Names[0]
+		}
+
+		containers = append(containers, containerruntime.Container{
+			ID:     rawContainer.ID,
+			Name:   name,
+			Labels: rawContainer.Labels,
+			Ports:  ports,
+		})
+	}
+
+	return containers, nil
+}
+
+func (r *Runtime) AwaitHealth(containerID string, timeout *time.Duration) error {
+	deadline := time.Now().Add(5 * time.Second)
+	if timeout != nil {
+		deadline = time.Now().Add(*timeout)
+	}
+
+	for time.Now().Before(deadline) {
+		result, err := r.run("inspect", "--format", "{{.State.Health.Status}}", containerID)
+		if err == nil && strings.TrimSpace(result) == "healthy" {
+			return nil
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	return errors.Errorf("Container %s did not become healthy in time", containerID)
+}
+
+func (r *Runtime) Build(options *containerruntime.BuildOptions) error {
+	args := []string{"build", "--tag", options.ImageName}
+
+	if options.DockerfilePath != "" {
+		args = append(args, "--file", options.DockerfilePath)
+	}
+
+	if options.NoCache {
+		args = append(args, "--no-cache")
+	}
+
+	for name, value := range options.BuildArgs {
+		args = append(args, "--build-arg", fmt.Sprintf("%s=%s", name, value))
+	}
+
+	args = append(args, options.ContextDir)
+
+	_, err := r.run(args...)
+	return err
+}
+
+func (r *Runtime) Push(imageName string) error {
+	_, err := r.run("push", imageName)
+	return err
+}
+
+func (r *Runtime) Logs(containerID string) (string, error) {
+	return r.run("logs", containerID)
+}
+
+// GetDefaultInvokeIPAddresses returns the address callers outside the container
+// network can use to reach a published port. Rootless podman has no docker0-style
+// bridge, so we fall back to the slirp4netns gateway (reachable from the host) or,
+// failing that, loopback
+func (r *Runtime) GetDefaultInvokeIPAddresses() ([]string, error) {
+	if r.rootless {
+		return []string{slirp4netnsGateway, "127.0.0.1"}, nil
+	}
+
+	return []string{"127.0.0.1"}, nil
+}
+
+// ImageExists returns whether image is already present locally
+func (r *Runtime) ImageExists(image string) (bool, error) {
+	if _, err := r.run("image", "exists", image); err != nil {
+
+		// "podman image exists" exits non-zero both when the image is genuinely
+		// missing and on unexpected failures; either way there's nothing more
+		// specific to report here, so treat it as "not present"
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Pull pulls image from its registry, authenticating with options if given
+func (r *Runtime) Pull(image string, options *containerruntime.PullOptions) error {
+	args := []string{"pull"}
+
+	if options != nil && options.Username != "" {
+		args = append(args, "--creds", fmt.Sprintf("%s:%s", options.Username, options.Password))
+	}
+
+	args = append(args, image)
+
+	_, err := r.run(args...)
+	if err != nil {
+		return errors.Wrap(err, "Failed to pull image")
+	}
+
+	return nil
+}
+
+// GetContainerHealthStatus returns containerID's health status, as reported by
+// podman inspect's State.Health.Status field
+func (r *Runtime) GetContainerHealthStatus(containerID string) (string, error) {
+	result, err := r.run("inspect", "--format", "{{.State.Health.Status}}", containerID)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to inspect container health status")
+	}
+
+	return strings.TrimSpace(result), nil
+}
+
+func (r *Runtime) run(args ...string) (string, error) {
+	quotedArgs := make([]string, len(args))
+	for i, arg := range args {
+		quotedArgs[i] = shellQuote(arg)
+	}
+
+	result, err := r.cmdRunner.Run(nil, "podman %s", strings.Join(quotedArgs, " "))
+	if err != nil {
+		return "", err
+	}
+
+	return result.Output, nil
+}
+
+// shellQuote wraps s in single quotes so the shell the cmdRunner hands the command
+// line to treats it as one opaque argument, even if it contains spaces or shell
+// metacharacters (container names, env values, labels, and volume paths all come from
+// function/namespace names and user-supplied config, so they cannot be trusted as-is).
+// A literal single quote is closed, escaped, and reopened, per POSIX shell quoting
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
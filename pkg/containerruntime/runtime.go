@@ -0,0 +1,117 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package containerruntime abstracts the container engine the local platform deploys
+// function containers to, so that a host without a reachable docker daemon can still
+// run functions (e.g. rootless podman on a hardened host)
+package containerruntime
+
+import "time"
+
+// RunOptions describes how to run a container
+type RunOptions struct {
+	Image         string
+	ContainerName string
+	Ports         map[int]int
+	Env           map[string]string
+	Labels        map[string]string
+	Volumes       map[string]string
+	Network       string
+}
+
+// PullOptions carries registry credentials for Pull, resolved from credstore the way
+// podman/skopeo do. Username/Password are left empty for anonymous pulls
+type PullOptions struct {
+	Username string
+	Password string
+}
+
+// Container describes a previously created container
+type Container struct {
+	ID     string
+	Name   string
+	Labels map[string]string
+	Ports  map[int]int
+}
+
+// GetContainerOptions filters containers returned by GetContainers
+type GetContainerOptions struct {
+	Name    string
+	Labels  map[string]string
+	Stopped bool
+}
+
+// BuildOptions describes how to build an image
+type BuildOptions struct {
+	ImageName      string
+	DockerfilePath string
+	ContextDir     string
+	BuildArgs      map[string]string
+	NoCache        bool
+}
+
+// Runtime abstracts the subset of container engine operations the local platform
+// needs. Implementations exist for docker (the default) and podman
+type Runtime interface {
+
+	// Run starts a container and returns its ID
+	Run(options *RunOptions) (string, error)
+
+	// Remove removes a container by ID or name
+	Remove(containerID string) error
+
+	// Load loads an image from a tar archive on disk
+	Load(archivePath string) error
+
+	// GetContainers returns containers matching options
+	GetContainers(options *GetContainerOptions) ([]Container, error)
+
+	// AwaitHealth blocks until the container becomes healthy or timeout elapses
+	AwaitHealth(containerID string, timeout *time.Duration) error
+
+	// Build builds an image
+	Build(options *BuildOptions) error
+
+	// Push pushes an image to a registry
+	Push(imageName string) error
+
+	// Logs returns the container's stdout/stderr logs
+	Logs(containerID string) (string, error)
+
+	// GetDefaultInvokeIPAddresses returns the address(es) a caller outside the
+	// container network can use to reach a published port. Rootless podman has no
+	// docker0-style bridge, so this differs per runtime
+	GetDefaultInvokeIPAddresses() ([]string, error)
+
+	// ImageExists returns whether image is already present locally
+	ImageExists(image string) (bool, error)
+
+	// Pull pulls image from its registry
+	Pull(image string, options *PullOptions) error
+
+	// GetContainerHealthStatus returns containerID's health status (e.g. docker
+	// inspect's State.Health.Status: "starting", "healthy", "unhealthy", or "" if the
+	// container has no configured healthcheck)
+	GetContainerHealthStatus(containerID string) (string, error)
+}
+
+// Name identifies a Runtime implementation, selected via NUCLIO_CONTAINER_RUNTIME
+type Name string
+
+const (
+	NameDocker Name = "docker"
+	NamePodman Name = "podman"
+)
@@ -0,0 +1,55 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package functionconfig
+
+// Additional FunctionState values mirroring Docker's health state vocabulary
+// (docker inspect's State.Health.Status), used alongside the existing
+// FunctionStateReady / FunctionStateError / FunctionStateBuilding. Values start at an
+// offset so they don't collide with the existing FunctionState enum
+const (
+	// FunctionStateStarting means the container is up but hasn't reported a health
+	// status yet (docker's "starting")
+	FunctionStateStarting FunctionState = iota + 100
+
+	// FunctionStateHealthy means the container reports a passing health check
+	FunctionStateHealthy
+
+	// FunctionStateUnhealthy means the container reports a failing health check but
+	// hasn't yet exhausted its restart retries (see local.Platform's
+	// recreateFunctionContainer); FunctionStateError is reserved for giving up
+	// permanently
+	FunctionStateUnhealthy
+
+	// FunctionStateNoHealthcheck means the container has no HEALTHCHECK configured,
+	// so its health cannot be determined (docker's "none")
+	FunctionStateNoHealthcheck
+)
+
+// DockerHealthStatusToFunctionState translates a docker inspect State.Health.Status
+// value into the corresponding FunctionState
+func DockerHealthStatusToFunctionState(dockerHealthStatus string) FunctionState {
+	switch dockerHealthStatus {
+	case "starting":
+		return FunctionStateStarting
+	case "healthy":
+		return FunctionStateHealthy
+	case "unhealthy":
+		return FunctionStateUnhealthy
+	default:
+		return FunctionStateNoHealthcheck
+	}
+}
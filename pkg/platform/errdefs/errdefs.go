@@ -0,0 +1,230 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package errdefs defines a small taxonomy of platform error marker interfaces
+// (NotFound, Conflict, InvalidParameter, Unavailable, Forbidden, System, NotModified,
+// AlreadyExists, Unhealthy) so callers (the dashboard, nuctl) can classify an error -
+// and map it to an HTTP status code - without substring-matching its message. A
+// platform method wraps a sentinel with one of the New* constructors below (e.g.
+// errdefs.NewNotFound) instead of returning a raw errors.Wrap string
+package errdefs
+
+// NotFound is implemented by errors describing a missing resource
+type NotFound interface {
+	NotFound() bool
+}
+
+// Conflict is implemented by errors describing a conflicting operation, e.g. two
+// concurrent creates of the same resource
+type Conflict interface {
+	Conflict() bool
+}
+
+// InvalidParameter is implemented by errors describing a malformed or oversized
+// request
+type InvalidParameter interface {
+	InvalidParameter() bool
+}
+
+// Unavailable is implemented by errors describing a resource that exists but is
+// temporarily unable to serve the request
+type Unavailable interface {
+	Unavailable() bool
+}
+
+// Forbidden is implemented by errors describing a disallowed operation
+type Forbidden interface {
+	Forbidden() bool
+}
+
+// System is implemented by errors describing an unexpected internal failure
+type System interface {
+	System() bool
+}
+
+// NotModified is implemented by errors describing a no-op (the resource already has
+// the requested state)
+type NotModified interface {
+	NotModified() bool
+}
+
+// AlreadyExists is implemented by errors describing a resource that already exists
+type AlreadyExists interface {
+	AlreadyExists() bool
+}
+
+// Unhealthy is implemented by errors describing a function whose container exists but
+// is failing its health check
+type Unhealthy interface {
+	Unhealthy() bool
+}
+
+type errNotFound struct{ error }
+
+func (e errNotFound) NotFound() bool { return true }
+
+// NewNotFound wraps err as a NotFound error
+func NewNotFound(err error) error { return errNotFound{err} }
+
+type errConflict struct{ error }
+
+func (e errConflict) Conflict() bool { return true }
+
+// NewConflict wraps err as a Conflict error
+func NewConflict(err error) error { return errConflict{err} }
+
+type errInvalidParameter struct{ error }
+
+func (e errInvalidParameter) InvalidParameter() bool { return true }
+
+// NewInvalidParameter wraps err as an InvalidParameter error
+func NewInvalidParameter(err error) error { return errInvalidParameter{err} }
+
+type errUnavailable struct{ error }
+
+func (e errUnavailable) Unavailable() bool { return true }
+
+// NewUnavailable wraps err as an Unavailable error
+func NewUnavailable(err error) error { return errUnavailable{err} }
+
+type errForbidden struct{ error }
+
+func (e errForbidden) Forbidden() bool { return true }
+
+// NewForbidden wraps err as a Forbidden error
+func NewForbidden(err error) error { return errForbidden{err} }
+
+type errSystem struct{ error }
+
+func (e errSystem) System() bool { return true }
+
+// NewSystem wraps err as a System error
+func NewSystem(err error) error { return errSystem{err} }
+
+type errNotModified struct{ error }
+
+func (e errNotModified) NotModified() bool { return true }
+
+// NewNotModified wraps err as a NotModified error
+func NewNotModified(err error) error { return errNotModified{err} }
+
+type errAlreadyExists struct{ error }
+
+func (e errAlreadyExists) AlreadyExists() bool { return true }
+
+// NewAlreadyExists wraps err as an AlreadyExists error
+func NewAlreadyExists(err error) error { return errAlreadyExists{err} }
+
+type errUnhealthy struct{ error }
+
+func (e errUnhealthy) Unhealthy() bool { return true }
+
+// NewUnhealthy wraps err as an Unhealthy error
+func NewUnhealthy(err error) error { return errUnhealthy{err} }
+
+// IsNotFound returns true if err, or any cause in its chain, is a NotFound error. A
+// typed marker anywhere in the chain takes precedence over walking further causes
+func IsNotFound(err error) bool {
+	return matches(err, func(candidate error) bool {
+		notFound, ok := candidate.(NotFound)
+		return ok && notFound.NotFound()
+	})
+}
+
+// IsConflict returns true if err, or any cause in its chain, is a Conflict error
+func IsConflict(err error) bool {
+	return matches(err, func(candidate error) bool {
+		conflict, ok := candidate.(Conflict)
+		return ok && conflict.Conflict()
+	})
+}
+
+// IsInvalidParameter returns true if err, or any cause in its chain, is an
+// InvalidParameter error
+func IsInvalidParameter(err error) bool {
+	return matches(err, func(candidate error) bool {
+		invalidParameter, ok := candidate.(InvalidParameter)
+		return ok && invalidParameter.InvalidParameter()
+	})
+}
+
+// IsUnavailable returns true if err, or any cause in its chain, is an Unavailable error
+func IsUnavailable(err error) bool {
+	return matches(err, func(candidate error) bool {
+		unavailable, ok := candidate.(Unavailable)
+		return ok && unavailable.Unavailable()
+	})
+}
+
+// IsForbidden returns true if err, or any cause in its chain, is a Forbidden error
+func IsForbidden(err error) bool {
+	return matches(err, func(candidate error) bool {
+		forbidden, ok := candidate.(Forbidden)
+		return ok && forbidden.Forbidden()
+	})
+}
+
+// IsSystem returns true if err, or any cause in its chain, is a System error
+func IsSystem(err error) bool {
+	return matches(err, func(candidate error) bool {
+		system, ok := candidate.(System)
+		return ok && system.System()
+	})
+}
+
+// IsNotModified returns true if err, or any cause in its chain, is a NotModified error
+func IsNotModified(err error) bool {
+	return matches(err, func(candidate error) bool {
+		notModified, ok := candidate.(NotModified)
+		return ok && notModified.NotModified()
+	})
+}
+
+// IsAlreadyExists returns true if err, or any cause in its chain, is an AlreadyExists
+// error
+func IsAlreadyExists(err error) bool {
+	return matches(err, func(candidate error) bool {
+		alreadyExists, ok := candidate.(AlreadyExists)
+		return ok && alreadyExists.AlreadyExists()
+	})
+}
+
+// IsUnhealthy returns true if err, or any cause in its chain, is an Unhealthy error
+func IsUnhealthy(err error) bool {
+	return matches(err, func(candidate error) bool {
+		unhealthy, ok := candidate.(Unhealthy)
+		return ok && unhealthy.Unhealthy()
+	})
+}
+
+// matches walks err's cause chain (via errors.Causer, the pattern used throughout
+// pkg/errors) looking for a cause that satisfies predicate
+func matches(err error, predicate func(error) bool) bool {
+	for err != nil {
+		if predicate(err) {
+			return true
+		}
+
+		causer, ok := err.(interface{ Cause() error })
+		if !ok {
+			return false
+		}
+
+		err = causer.Cause()
+	}
+
+	return false
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package events provides a pluggable function lifecycle event bus, in the spirit of
+// libpod's Eventer: platforms emit structured events as functions move through their
+// lifecycle, and operators can select a sink (none, a rotating JSONL log file, or
+// journald on Linux) to persist and tail them
+package events
+
+import (
+	"context"
+	"sync"
+
+	"github.com/nuclio/nuclio/pkg/common"
+
+	"github.com/nuclio/logger"
+)
+
+// Reason identifies the kind of lifecycle transition an Event describes
+type Reason string
+
+const (
+	ReasonFunctionCreating        Reason = "FunctionCreating"
+	ReasonFunctionBuilding        Reason = "FunctionBuilding"
+	ReasonFunctionReady           Reason = "FunctionReady"
+	ReasonFunctionError           Reason = "FunctionError"
+	ReasonFunctionDeleted         Reason = "FunctionDeleted"
+	ReasonContainerHealthLost     Reason = "ContainerHealthLost"
+	ReasonContainerHealthRestored Reason = "ContainerHealthRestored"
+	ReasonProjectCreated          Reason = "ProjectCreated"
+	ReasonProjectDeleted          Reason = "ProjectDeleted"
+	ReasonFunctionEventCreated    Reason = "FunctionEventCreated"
+	ReasonFunctionEventDeleted    Reason = "FunctionEventDeleted"
+)
+
+// Event is a single structured lifecycle event
+type Event struct {
+	Timestamp   int64  `json:"timestamp"`
+	Reason      Reason `json:"reason"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Image       string `json:"image,omitempty"`
+	ContainerID string `json:"containerID,omitempty"`
+	HTTPPort    int    `json:"httpPort,omitempty"`
+}
+
+// Filter narrows down which events GetEvents streams back
+type Filter struct {
+	Namespace string
+	Name      string
+
+	// Labels is not consulted by Matches: Event carries no labels, so setting it does
+	// not narrow the stream
+	Labels  map[string]string
+	Reasons []Reason
+	Since   int64
+}
+
+// Matches returns true if event satisfies the filter
+func (f *Filter) Matches(event Event) bool {
+	if f.Namespace != "" && f.Namespace != event.Namespace {
+		return false
+	}
+
+	if f.Name != "" && f.Name != event.Name {
+		return false
+	}
+
+	if f.Since != 0 && event.Timestamp < f.Since {
+		return false
+	}
+
+	if len(f.Reasons) > 0 {
+		found := false
+		for _, reason := range f.Reasons {
+			if reason == event.Reason {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Sink persists or forwards events
+type Sink interface {
+	Write(event Event) error
+}
+
+// Eventer publishes lifecycle events to a Sink and fans them out to streaming readers
+type Eventer struct {
+	logger logger.Logger
+	sink   Sink
+
+	lock      sync.Mutex
+	listeners []chan Event
+}
+
+const backendEnvVar = "NUCLIO_EVENTS_BACKEND"
+
+// NewEventer creates an Eventer backed by the sink selected via NUCLIO_EVENTS_BACKEND
+// (none|logfile|journald), defaulting to none
+func NewEventer(parentLogger logger.Logger, logFilePath string) (*Eventer, error) {
+	backend := common.GetEnvOrDefaultString(backendEnvVar, "none")
+
+	sink, err := newSink(parentLogger, backend, logFilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Eventer{
+		logger: parentLogger.GetChild("eventer"),
+		sink:   sink,
+	}, nil
+}
+
+// Publish writes event to the configured sink and fans it out to any active
+// GetEvents listeners
+func (e *Eventer) Publish(event Event) {
+	if e.sink != nil {
+		if err := e.sink.Write(event); err != nil {
+			e.logger.WarnWith("Failed to write lifecycle event", "err", err, "reason", event.Reason)
+		}
+	}
+
+	e.lock.Lock()
+	listeners := append([]chan Event{}, e.listeners...)
+	e.lock.Unlock()
+
+	for _, listener := range listeners {
+		select {
+		case listener <- event:
+		default:
+
+			// a slow listener shouldn't block publishing
+		}
+	}
+}
+
+// GetEvents returns a channel streaming future events matching filter. The channel is
+// closed when ctx is done
+func (e *Eventer) GetEvents(ctx context.Context, filter *Filter) <-chan Event {
+	raw := make(chan Event, 64)
+	filtered := make(chan Event, 64)
+
+	e.lock.Lock()
+	e.listeners = append(e.listeners, raw)
+	e.lock.Unlock()
+
+	go func() {
+		defer close(filtered)
+
+		for {
+			select {
+			case <-ctx.Done():
+				e.removeListener(raw)
+				return
+			case event := <-raw:
+				if filter == nil || filter.Matches(event) {
+					filtered <- event
+				}
+			}
+		}
+	}()
+
+	return filtered
+}
+
+func (e *Eventer) removeListener(target chan Event) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for i, listener := range e.listeners {
+		if listener == target {
+			e.listeners = append(e.listeners[:i], e.listeners[i+1:]...)
+			break
+		}
+	}
+}
+
+func newSink(parentLogger logger.Logger, backend string, logFilePath string) (Sink, error) {
+	switch backend {
+	case "logfile":
+		return newLogFileSink(logFilePath)
+	case "journald":
+		return newJournaldSink(parentLogger)
+	default:
+		return nil, nil
+	}
+}
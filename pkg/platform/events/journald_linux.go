@@ -0,0 +1,52 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"github.com/coreos/go-systemd/journal"
+	"github.com/nuclio/nuclio/pkg/errors"
+
+	"github.com/nuclio/logger"
+)
+
+type journaldSink struct {
+	logger logger.Logger
+}
+
+func newJournaldSink(parentLogger logger.Logger) (Sink, error) {
+	if !journal.Enabled() {
+		return nil, errors.New("journald is not available on this host")
+	}
+
+	return &journaldSink{logger: parentLogger.GetChild("journald-events")}, nil
+}
+
+// Write sends event to journald, tagged with its reason so it can be filtered with
+// "journalctl SYSLOG_IDENTIFIER=nuclio NUCLIO_REASON=<reason>"
+func (s *journaldSink) Write(event Event) error {
+	fields := map[string]string{
+		"SYSLOG_IDENTIFIER": "nuclio",
+		"NUCLIO_REASON":     string(event.Reason),
+		"NUCLIO_NAMESPACE":  event.Namespace,
+		"NUCLIO_NAME":       event.Name,
+	}
+
+	return journal.Send(string(event.Reason), journal.PriInfo, fields)
+}
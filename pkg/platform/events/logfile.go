@@ -0,0 +1,92 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/nuclio/nuclio/pkg/errors"
+)
+
+// maxLogFileSize is the size at which the JSONL log file is rotated to a ".1" sibling
+const maxLogFileSize = 10 * 1048576
+
+type logFileSink struct {
+	lock sync.Mutex
+	path string
+	file *os.File
+}
+
+func newLogFileSink(path string) (*logFileSink, error) {
+	if path == "" {
+		return nil, errors.New("Log file path must be set for the logfile events backend")
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to open events log file")
+	}
+
+	return &logFileSink{path: path, file: file}, nil
+}
+
+// Write appends event as a single JSON line, rotating the file once it exceeds
+// maxLogFileSize
+func (s *logFileSink) Write(event Event) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.rotateIfNeeded(); err != nil {
+		return err
+	}
+
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal event")
+	}
+
+	_, err = s.file.Write(append(encoded, '\n'))
+	return err
+}
+
+func (s *logFileSink) rotateIfNeeded() error {
+	info, err := s.file.Stat()
+	if err != nil {
+		return errors.Wrap(err, "Failed to stat events log file")
+	}
+
+	if info.Size() < maxLogFileSize {
+		return nil
+	}
+
+	if err := s.file.Close(); err != nil {
+		return errors.Wrap(err, "Failed to close events log file for rotation")
+	}
+
+	if err := os.Rename(s.path, s.path+".1"); err != nil {
+		return errors.Wrap(err, "Failed to rotate events log file")
+	}
+
+	s.file, err = os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "Failed to reopen events log file after rotation")
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package platform
+
+import "github.com/nuclio/nuclio/pkg/functionconfig"
+
+// GetFunctionsOptions specifies how to filter the result of GetFunctions
+type GetFunctionsOptions struct {
+	Namespace string
+	Name      string
+	Labels    string
+
+	// Health filters the result down to functions whose status is in this state
+	// (e.g. FunctionStateUnhealthy, equivalent to `docker ps --filter health=...`).
+	// The zero value means no filtering by health
+	Health functionconfig.FunctionState
+}
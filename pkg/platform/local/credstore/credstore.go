@@ -0,0 +1,92 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package credstore resolves registry credentials the way podman/skopeo do: from
+// $REGISTRY_AUTH_FILE if set, falling back to ~/.docker/config.json. It exists so the
+// local platform can pull/push images from a private RunRegistry without requiring
+// callers to pass credentials through CreateFunctionOptions
+package credstore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nuclio/nuclio/pkg/errors"
+)
+
+// Credentials holds a single registry's basic-auth credentials
+type Credentials struct {
+	Username string
+	Password string
+}
+
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth string `json:"auth"`
+	} `json:"auths"`
+}
+
+// Resolve returns credentials for registryHost, or nil (with no error) if none are
+// configured. It reads $REGISTRY_AUTH_FILE when that is set, and ~/.docker/config.json
+// only when it is not
+func Resolve(registryHost string) (*Credentials, error) {
+	if authFile := os.Getenv("REGISTRY_AUTH_FILE"); authFile != "" {
+		return resolveFromFile(authFile, registryHost)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to resolve home directory")
+	}
+
+	return resolveFromFile(filepath.Join(home, ".docker", "config.json"), registryHost)
+}
+
+func resolveFromFile(path string, registryHost string) (*Credentials, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrapf(err, "Failed to read docker config at %s", path)
+	}
+
+	var config dockerConfig
+	if err := json.Unmarshal(contents, &config); err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse docker config at %s", path)
+	}
+
+	entry, found := config.Auths[registryHost]
+	if !found {
+		return nil, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to decode auth for registry %s", registryHost)
+	}
+
+	usernamePassword := strings.SplitN(string(decoded), ":", 2)
+	if len(usernamePassword) != 2 {
+		return nil, errors.Errorf("Malformed auth entry for registry %s", registryHost)
+	}
+
+	return &Credentials{Username: usernamePassword[0], Password: usernamePassword[1]}, nil
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"sync"
+	"time"
+)
+
+// maxHealthLogEntries bounds how many health check results are kept per function,
+// mirroring podman's healthcheck log (drop oldest once full)
+const maxHealthLogEntries = 5
+
+// HealthCheckLogEntry records the outcome of a single health check
+type HealthCheckLogEntry struct {
+	Timestamp     time.Time
+	Healthy       bool
+	StderrSnippet string
+}
+
+// healthLog is a per-function ring buffer of HealthCheckLogEntry plus a streak
+// counter of consecutive failures, used to decide whether to retry a restart or give
+// up and transition the function to FunctionStateError permanently
+type healthLog struct {
+	entries        []HealthCheckLogEntry
+	failureStreak  int
+	restartAttempt int
+}
+
+// healthLogStore keeps one healthLog per function, keyed by its unique ID
+// ("namespace:name")
+type healthLogStore struct {
+	lock sync.Mutex
+	logs map[string]*healthLog
+}
+
+func newHealthLogStore() *healthLogStore {
+	return &healthLogStore{logs: map[string]*healthLog{}}
+}
+
+// record appends an entry for functionID, dropping the oldest if the log is full, and
+// returns the updated consecutive-failure streak
+func (s *healthLogStore) record(functionID string, entry HealthCheckLogEntry) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	log, found := s.logs[functionID]
+	if !found {
+		log = &healthLog{}
+		s.logs[functionID] = log
+	}
+
+	log.entries = append(log.entries, entry)
+	if len(log.entries) > maxHealthLogEntries {
+		log.entries = log.entries[len(log.entries)-maxHealthLogEntries:]
+	}
+
+	if entry.Healthy {
+		log.failureStreak = 0
+		log.restartAttempt = 0
+	} else {
+		log.failureStreak++
+	}
+
+	return log.failureStreak
+}
+
+// nextRestartAttempt increments and returns the restart attempt counter for
+// functionID, used to compute exponential backoff
+func (s *healthLogStore) nextRestartAttempt(functionID string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	log, found := s.logs[functionID]
+	if !found {
+		log = &healthLog{}
+		s.logs[functionID] = log
+	}
+
+	log.restartAttempt++
+	return log.restartAttempt
+}
+
+// get returns a copy of functionID's log entries, oldest first
+func (s *healthLogStore) get(functionID string) []HealthCheckLogEntry {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	log, found := s.logs[functionID]
+	if !found {
+		return nil
+	}
+
+	entries := make([]HealthCheckLogEntry, len(log.entries))
+	copy(entries, log.entries)
+
+	return entries
+}
+
+// restartBackoff returns the exponential backoff (1s, 2s, 4s, ...) for the given
+// restart attempt, counting from 1
+func restartBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	backoff := time.Second
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+	}
+
+	return backoff
+}
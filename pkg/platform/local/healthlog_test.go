@@ -0,0 +1,110 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package local
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HealthLogStoreTestSuite struct {
+	suite.Suite
+}
+
+// TestRecordTracksFailureStreakAndResetsOnHealthy covers the state machine
+// markFunctionUnhealthy relies on to decide whether to keep retrying a restart
+func (suite *HealthLogStoreTestSuite) TestRecordTracksFailureStreakAndResetsOnHealthy() {
+	store := newHealthLogStore()
+
+	suite.Require().Equal(1, store.record("default:fn", HealthCheckLogEntry{Healthy: false}))
+	suite.Require().Equal(2, store.record("default:fn", HealthCheckLogEntry{Healthy: false}))
+	suite.Require().Equal(3, store.record("default:fn", HealthCheckLogEntry{Healthy: false}))
+
+	// a single healthy result resets the streak, as markFunctionUnhealthy expects
+	// when deciding the function has recovered
+	suite.Require().Equal(0, store.record("default:fn", HealthCheckLogEntry{Healthy: true}))
+
+	suite.Require().Equal(1, store.record("default:fn", HealthCheckLogEntry{Healthy: false}))
+}
+
+// TestRecordDropsOldestBeyondCap ensures the ring buffer never grows past
+// maxHealthLogEntries
+func (suite *HealthLogStoreTestSuite) TestRecordDropsOldestBeyondCap() {
+	store := newHealthLogStore()
+
+	for i := 0; i < maxHealthLogEntries+3; i++ {
+		store.record("default:fn", HealthCheckLogEntry{Healthy: false, Timestamp: time.Unix(int64(i), 0)})
+	}
+
+	entries := store.get("default:fn")
+	suite.Require().Len(entries, maxHealthLogEntries)
+
+	// the oldest entries should have been dropped, leaving only the most recent ones
+	suite.Require().Equal(int64(3), entries[0].Timestamp.Unix())
+}
+
+// TestNextRestartAttemptIncrementsAndResetsOnHealthy covers the counter
+// restartBackoff is driven by, including the reset a healthy result performs so a
+// function that flaps doesn't inherit a stale, oversized backoff
+func (suite *HealthLogStoreTestSuite) TestNextRestartAttemptIncrementsAndResetsOnHealthy() {
+	store := newHealthLogStore()
+
+	suite.Require().Equal(1, store.nextRestartAttempt("default:fn"))
+	suite.Require().Equal(2, store.nextRestartAttempt("default:fn"))
+
+	store.record("default:fn", HealthCheckLogEntry{Healthy: true})
+
+	suite.Require().Equal(1, store.nextRestartAttempt("default:fn"))
+}
+
+// TestRestartBackoffIsExponentialAndFloored covers the backoff curve
+// markFunctionUnhealthy sleeps (now cancellably) between restart attempts
+func (suite *HealthLogStoreTestSuite) TestRestartBackoffIsExponentialAndFloored() {
+	suite.Require().Equal(time.Second, restartBackoff(0))
+	suite.Require().Equal(time.Second, restartBackoff(1))
+	suite.Require().Equal(2*time.Second, restartBackoff(2))
+	suite.Require().Equal(4*time.Second, restartBackoff(3))
+	suite.Require().Equal(8*time.Second, restartBackoff(4))
+}
+
+// TestConcurrentAccessIsSafe exercises healthLogStore's locking under -race: several
+// functions are recorded against concurrently, matching the real usage where
+// ValidateFunctionContainersHealthiness checks every function in a namespace
+func (suite *HealthLogStoreTestSuite) TestConcurrentAccessIsSafe() {
+	store := newHealthLogStore()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				store.record("default:fn", HealthCheckLogEntry{Healthy: j%2 == 0})
+				store.nextRestartAttempt("default:fn")
+				store.get("default:fn")
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestHealthLogStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(HealthLogStoreTestSuite))
+}
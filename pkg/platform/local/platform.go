@@ -24,18 +24,27 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"os/signal"
 	"path"
-	"strconv"
+	"runtime/pprof"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/nuclio/nuclio/pkg/cmdrunner"
 	"github.com/nuclio/nuclio/pkg/common"
 	"github.com/nuclio/nuclio/pkg/containerimagebuilderpusher"
+	"github.com/nuclio/nuclio/pkg/containerruntime"
+	"github.com/nuclio/nuclio/pkg/containerruntime/docker"
+	"github.com/nuclio/nuclio/pkg/containerruntime/podman"
 	"github.com/nuclio/nuclio/pkg/dockerclient"
 	"github.com/nuclio/nuclio/pkg/errors"
 	"github.com/nuclio/nuclio/pkg/functionconfig"
 	"github.com/nuclio/nuclio/pkg/platform"
 	"github.com/nuclio/nuclio/pkg/platform/abstract"
+	"github.com/nuclio/nuclio/pkg/platform/errdefs"
+	"github.com/nuclio/nuclio/pkg/platform/events"
+	"github.com/nuclio/nuclio/pkg/platform/local/credstore"
 	"github.com/nuclio/nuclio/pkg/processor"
 	"github.com/nuclio/nuclio/pkg/processor/config"
 
@@ -49,18 +58,40 @@ type Platform struct {
 	*abstract.Platform
 	cmdRunner                             cmdrunner.CmdRunner
 	dockerClient                          dockerclient.Client
+	containerRuntime                      containerruntime.Runtime
+	eventer                               *events.Eventer
 	localStore                            *store
 	checkFunctionContainersHealthiness    bool
 	functionContainersHealthinessTimeout  time.Duration
 	functionContainersHealthinessInterval time.Duration
+	maxRestartRetries                     int
+	healthLogs                            *healthLogStore
+
+	ctx          context.Context
+	cancel       context.CancelFunc
+	shutdownOnce sync.Once
+	shutdownDone chan struct{}
+	backgroundWG sync.WaitGroup
 }
 
 const Mib = 1048576
 
-// NewPlatform instantiates a new local platform
-func NewPlatform(parentLogger logger.Logger) (*Platform, error) {
+// containerRuntimeEnvVar selects the container engine the local platform talks to.
+// Docker remains the default and is the only backend that currently drives image
+// builds (via ContainerBuilder); podman is wired in for container lifecycle and
+// invoke-address resolution, with the rest landing incrementally
+const containerRuntimeEnvVar = "NUCLIO_CONTAINER_RUNTIME"
+
+// NewPlatform instantiates a new local platform. ctx bounds the platform's background
+// goroutine (the healthiness validator) and, once done, makes CreateFunction refuse new
+// deployments; deployments already in flight are not cancelled. Cancelling it or
+// calling the returned Platform's Close has the same effect
+func NewPlatform(ctx context.Context, parentLogger logger.Logger) (*Platform, error) {
 	newPlatform := &Platform{}
 
+	newPlatform.ctx, newPlatform.cancel = context.WithCancel(ctx)
+	newPlatform.shutdownDone = make(chan struct{})
+
 	// create base
 	newAbstractPlatform, err := abstract.NewPlatform(parentLogger, newPlatform)
 	if err != nil {
@@ -74,6 +105,8 @@ func NewPlatform(parentLogger logger.Logger) (*Platform, error) {
 	newPlatform.checkFunctionContainersHealthiness = common.GetEnvOrDefaultBool("NUCLIO_CHECK_FUNCTION_CONTAINERS_HEALTHINESS", false)
 	newPlatform.functionContainersHealthinessTimeout = time.Second * 5
 	newPlatform.functionContainersHealthinessInterval = time.Second * 30
+	newPlatform.maxRestartRetries = common.GetEnvOrDefaultInt("NUCLIO_MAX_FUNCTION_RESTART_RETRIES", 3)
+	newPlatform.healthLogs = newHealthLogStore()
 
 	// create a command runner
 	if newPlatform.cmdRunner, err = cmdrunner.NewShellRunner(newPlatform.Logger); err != nil {
@@ -89,18 +122,54 @@ func NewPlatform(parentLogger logger.Logger) (*Platform, error) {
 		return nil, errors.Wrap(err, "Failed to create docker client")
 	}
 
+	// pick a container runtime backend. Every container lifecycle operation
+	// (deployFunction, DeleteFunction, healthiness checks) goes through whichever one
+	// is selected; only image builds still go through ContainerBuilder, which remains
+	// docker-only for now
+	runtimeName := containerruntime.Name(common.GetEnvOrDefaultString(containerRuntimeEnvVar, string(containerruntime.NameDocker)))
+	switch runtimeName {
+	case containerruntime.NamePodman:
+		rootless := common.GetEnvOrDefaultBool("NUCLIO_PODMAN_ROOTLESS", true)
+
+		if newPlatform.containerRuntime, err = podman.NewRuntime(newPlatform.Logger, rootless); err != nil {
+			return nil, errors.Wrap(err, "Failed to create podman container runtime")
+		}
+
+	default:
+		if newPlatform.containerRuntime, err = docker.NewRuntime(newPlatform.Logger, newPlatform.dockerClient); err != nil {
+			return nil, errors.Wrap(err, "Failed to create docker container runtime")
+		}
+	}
+
 	// create a local store for configs and stuff
 	if newPlatform.localStore, err = newStore(parentLogger, newPlatform, newPlatform.dockerClient); err != nil {
 		return nil, errors.Wrap(err, "Failed to create local store")
 	}
 
+	// create the lifecycle event bus. NUCLIO_EVENTS_BACKEND selects the sink (none by
+	// default); NUCLIO_EVENTS_LOGFILE_PATH is consulted when the backend is "logfile"
+	if newPlatform.eventer, err = events.NewEventer(newPlatform.Logger,
+		common.GetEnvOrDefaultString("NUCLIO_EVENTS_LOGFILE_PATH", "/tmp/nuclio-events.log")); err != nil {
+		return nil, errors.Wrap(err, "Failed to create lifecycle eventer")
+	}
+
 	// ignite goroutine to check function container healthiness
 	if newPlatform.checkFunctionContainersHealthiness {
 		newPlatform.Logger.DebugWith("Igniting container healthiness validator")
+		newPlatform.backgroundWG.Add(1)
 		go func(newPlatform *Platform) {
+			defer newPlatform.backgroundWG.Done()
+
 			uptimeTicker := time.NewTicker(newPlatform.functionContainersHealthinessInterval)
-			for range uptimeTicker.C {
-				newPlatform.ValidateFunctionContainersHealthiness()
+			defer uptimeTicker.Stop()
+
+			for {
+				select {
+				case <-uptimeTicker.C:
+					newPlatform.ValidateFunctionContainersHealthiness(newPlatform.ctx)
+				case <-newPlatform.ctx.Done():
+					return
+				}
 			}
 		}(newPlatform)
 	}
@@ -109,6 +178,10 @@ func NewPlatform(parentLogger logger.Logger) (*Platform, error) {
 
 // CreateFunction will simply run a docker image
 func (p *Platform) CreateFunction(createFunctionOptions *platform.CreateFunctionOptions) (*platform.CreateFunctionResult, error) {
+	if p.ctx.Err() != nil {
+		return nil, errdefs.NewUnavailable(errors.New("Platform is shutting down, refusing new deployments"))
+	}
+
 	var previousHTTPPort int
 	var err error
 	var existingFunctionConfig *functionconfig.ConfigWithStatus
@@ -129,9 +202,12 @@ func (p *Platform) CreateFunction(createFunctionOptions *platform.CreateFunction
 		return nil, errors.Wrap(err, "Create function options validation failed")
 	}
 
-	// local currently doesn't support registries of any kind. remove push / run registry
-	createFunctionOptions.FunctionConfig.Spec.RunRegistry = ""
-	createFunctionOptions.FunctionConfig.Spec.Build.Registry = ""
+	p.publishFunctionEvent(events.ReasonFunctionCreating, createFunctionOptions.FunctionConfig.Meta, "", "", 0)
+
+	// Spec.Build.Registry / Spec.RunRegistry, when set, are now honored: the built
+	// image is pushed to Build.Registry and deployFunction pulls from RunRegistry if
+	// it isn't present locally. This unblocks multi-node local deployments and CI
+	// pipelines that build once and deploy many
 
 	// it's possible to pass a function without specifying any meta in the request, in that case skip getting existing function
 	if createFunctionOptions.FunctionConfig.Meta.Namespace != "" && createFunctionOptions.FunctionConfig.Meta.Name != "" {
@@ -155,6 +231,9 @@ func (p *Platform) CreateFunction(createFunctionOptions *platform.CreateFunction
 		}
 	}
 
+	// reportCreationError persists the failure on the function status and returns the
+	// error the caller should propagate, classified via errdefs so the dashboard can
+	// map it to an HTTP status code without substring matching
 	reportCreationError := func(creationError error) error {
 		createFunctionOptions.Logger.WarnWith("Create function failed, setting function status",
 			"err", creationError)
@@ -162,19 +241,26 @@ func (p *Platform) CreateFunction(createFunctionOptions *platform.CreateFunction
 		errorStack := bytes.Buffer{}
 		errors.PrintErrorStack(&errorStack, creationError, 20)
 
-		// cut messages that are too big
+		// cut messages that are too big and classify the error accordingly
 		if errorStack.Len() >= 4*Mib {
 			errorStack.Truncate(4 * Mib)
+			creationError = errdefs.NewInvalidParameter(creationError)
 		}
 
 		// post logs and error
-		return p.localStore.createOrUpdateFunction(&functionconfig.ConfigWithStatus{
+		if storeErr := p.localStore.createOrUpdateFunction(&functionconfig.ConfigWithStatus{
 			Config: createFunctionOptions.FunctionConfig,
 			Status: functionconfig.Status{
 				State:   functionconfig.FunctionStateError,
 				Message: errorStack.String(),
 			},
-		})
+		}); storeErr != nil {
+			createFunctionOptions.Logger.WarnWith("Failed to persist function error status", "err", storeErr)
+		}
+
+		p.publishFunctionEvent(events.ReasonFunctionError, createFunctionOptions.FunctionConfig.Meta, "", "", 0)
+
+		return creationError
 	}
 
 	onAfterConfigUpdated := func(updatedFunctionConfig *functionconfig.Config) error {
@@ -191,6 +277,8 @@ func (p *Platform) CreateFunction(createFunctionOptions *platform.CreateFunction
 			return errors.Wrap(err, "Failed to create function")
 		}
 
+		p.publishFunctionEvent(events.ReasonFunctionBuilding, createFunctionOptions.FunctionConfig.Meta, "", "", 0)
+
 		previousHTTPPort, err = p.deletePreviousContainers(createFunctionOptions)
 		if err != nil {
 			return errors.Wrap(err, "Failed to delete previous containers")
@@ -206,14 +294,20 @@ func (p *Platform) CreateFunction(createFunctionOptions *platform.CreateFunction
 
 	onAfterBuild := func(buildResult *platform.CreateFunctionBuildResult, buildErr error) (*platform.CreateFunctionResult, error) {
 		if buildErr != nil {
-			reportCreationError(buildErr) // nolint: errcheck
-			return nil, buildErr
+			return nil, reportCreationError(buildErr)
+		}
+
+		if registry := createFunctionOptions.FunctionConfig.Spec.Build.Registry; registry != "" {
+			createFunctionOptions.Logger.InfoWith("Pushing built image to registry", "registry", registry)
+
+			if err := p.ContainerBuilder.Push(buildResult.Image); err != nil {
+				return nil, reportCreationError(errors.Wrap(err, "Failed to push built image to registry"))
+			}
 		}
 
 		createFunctionResult, deployErr := p.deployFunction(createFunctionOptions, previousHTTPPort)
 		if deployErr != nil {
-			reportCreationError(deployErr) // nolint: errcheck
-			return nil, deployErr
+			return nil, reportCreationError(deployErr)
 		}
 
 		// update the function
@@ -227,6 +321,9 @@ func (p *Platform) CreateFunction(createFunctionOptions *platform.CreateFunction
 			return nil, errors.Wrap(err, "Failed to update function with state")
 		}
 
+		p.publishFunctionEvent(events.ReasonFunctionReady, createFunctionOptions.FunctionConfig.Meta,
+			createFunctionResult.Image, createFunctionResult.ContainerID, createFunctionResult.Port)
+
 		return createFunctionResult, nil
 	}
 
@@ -237,6 +334,20 @@ func (p *Platform) CreateFunction(createFunctionOptions *platform.CreateFunction
 		if err != nil {
 			return nil, errors.Wrap(err, "Failed to load docker image from archive")
 		}
+
+		// a loaded tar's image name may not match RunRegistry - re-tag it so
+		// deployFunction's pull-if-missing check and the eventual docker run agree
+		// on where to find it
+		if runRegistry := createFunctionOptions.FunctionConfig.Spec.RunRegistry; runRegistry != "" {
+			image := createFunctionOptions.FunctionConfig.Spec.Image
+			retaggedImage := fmt.Sprintf("%s/%s", runRegistry, image)
+
+			if err := p.dockerClient.Tag(image, retaggedImage); err != nil {
+				return nil, errors.Wrap(err, "Failed to re-tag loaded image into run registry")
+			}
+
+			createFunctionOptions.FunctionConfig.Spec.Image = retaggedImage
+		}
 	}
 
 	// wrap the deployer's deploy with the base HandleDeployFunction to provide lots of
@@ -270,6 +381,12 @@ func (p *Platform) GetFunctions(getFunctionsOptions *platform.GetFunctionsOption
 			continue
 		}
 
+		// filter by health state (e.g. "only unhealthy functions"), matching docker ps
+		// --filter health=...; the zero value (unset) means no filtering
+		if getFunctionsOptions.Health != 0 && localStoreFunction.GetStatus().State != getFunctionsOptions.Health {
+			continue
+		}
+
 		// enrich with build logs
 		if deployLogStream, exists := p.DeployLogStreams[localStoreFunction.GetConfig().Meta.GetUniqueID()]; exists {
 			deployLogStream.ReadLogs(nil, &localStoreFunction.GetStatus().Logs)
@@ -293,9 +410,10 @@ func (p *Platform) DeleteFunction(deleteFunctionOptions *platform.DeleteFunction
 	err := p.localStore.deleteFunction(&deleteFunctionOptions.FunctionConfig.Meta)
 	if err != nil {
 
-		// propagate not found errors
-		if err == nuclio.ErrNotFound {
-			return err
+		// propagate not found errors, classified so callers can map them to a 404
+		// without comparing against the sentinel directly
+		if err == nuclio.ErrNotFound || errdefs.IsNotFound(err) {
+			return errdefs.NewNotFound(err)
 		}
 
 		p.Logger.WarnWith("Failed to delete function from local store", "err", err.Error())
@@ -331,7 +449,7 @@ func (p *Platform) DeleteFunction(deleteFunctionOptions *platform.DeleteFunction
 		return errors.Wrap(err, "Failed to delete function events")
 	}
 
-	getContainerOptions := &dockerclient.GetContainerOptions{
+	getContainerOptions := &containerruntime.GetContainerOptions{
 		Labels: map[string]string{
 			"nuclio.io/platform":      "local",
 			"nuclio.io/namespace":     deleteFunctionOptions.FunctionConfig.Meta.Namespace,
@@ -339,7 +457,7 @@ func (p *Platform) DeleteFunction(deleteFunctionOptions *platform.DeleteFunction
 		},
 	}
 
-	containersInfo, err := p.dockerClient.GetContainers(getContainerOptions)
+	containersInfo, err := p.containerRuntime.GetContainers(getContainerOptions)
 	if err != nil {
 		return errors.Wrap(err, "Failed to get containers")
 	}
@@ -351,13 +469,15 @@ func (p *Platform) DeleteFunction(deleteFunctionOptions *platform.DeleteFunction
 	// iterate over contains and delete them. It's possible that under some weird circumstances
 	// there are a few instances of this function in the namespace
 	for _, containerInfo := range containersInfo {
-		if err := p.dockerClient.RemoveContainer(containerInfo.ID); err != nil {
+		if err := p.containerRuntime.Remove(containerInfo.ID); err != nil {
 			return err
 		}
 	}
 
 	p.Logger.InfoWith("Function deleted", "name", deleteFunctionOptions.FunctionConfig.Meta.Name)
 
+	p.publishFunctionEvent(events.ReasonFunctionDeleted, deleteFunctionOptions.FunctionConfig.Meta, "", "", 0)
+
 	return nil
 }
 
@@ -373,6 +493,83 @@ func (p *Platform) GetName() string {
 	return "local"
 }
 
+// Close cancels the platform's context, refuses new CreateFunction /
+// deletePreviousContainers calls, and waits for background goroutines (the
+// healthiness validator, in-flight health probes) to return. It is safe to call more
+// than once
+func (p *Platform) Close() {
+	p.shutdownOnce.Do(func() {
+		p.cancel()
+
+		go func() {
+			p.backgroundWG.Wait()
+			close(p.shutdownDone)
+		}()
+	})
+
+	<-p.shutdownDone
+}
+
+// Shutdown is Close's context-aware counterpart: it cancels the platform's context (so
+// in-flight health probes abort and new CreateFunction / deletePreviousContainers
+// calls are refused) and waits for background goroutines to drain, but gives up and
+// returns ctx's error if ctx is done first. Orchestrators (systemd, a k8s preStop hook)
+// that need a bounded shutdown should use this instead of Close. It is safe to call
+// more than once, and safe to call alongside Close
+func (p *Platform) Shutdown(ctx context.Context) error {
+	p.shutdownOnce.Do(func() {
+		p.cancel()
+
+		go func() {
+			p.backgroundWG.Wait()
+			close(p.shutdownDone)
+		}()
+	})
+
+	select {
+	case <-p.shutdownDone:
+		return nil
+	case <-ctx.Done():
+		return errdefs.NewUnavailable(errors.Wrap(ctx.Err(), "Timed out waiting for platform shutdown"))
+	}
+}
+
+// InstallSignalTrap installs a handler that calls Close on SIGINT/SIGTERM, force-exits
+// on the third repeat of either, and - when debug is true - dumps all goroutines to
+// stderr on SIGQUIT. It does not read NUCLIO_DEBUG or any other environment variable
+// itself; debug is taken as passed. It returns immediately; the handler runs in its own
+// goroutine
+func (p *Platform) InstallSignalTrap(debug bool) {
+	signalChan := make(chan os.Signal, 1)
+	signals := []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	if debug {
+		signals = append(signals, syscall.SIGQUIT)
+	}
+	signal.Notify(signalChan, signals...)
+
+	go func() {
+		terminationCount := 0
+
+		for sig := range signalChan {
+			if debug && sig == syscall.SIGQUIT {
+				_ = pprof.Lookup("goroutine").WriteTo(os.Stderr, 2) // nolint: errcheck
+				continue
+			}
+
+			terminationCount++
+			p.Logger.WarnWith("Received termination signal, shutting down",
+				"signal", sig, "count", terminationCount)
+
+			if terminationCount >= 3 {
+				p.Logger.Warn("Received termination signal 3 times, forcing exit")
+				os.Exit(1)
+			}
+
+			go p.Close()
+		}
+	}()
+}
+
 func (p *Platform) GetNodes() ([]platform.Node, error) {
 
 	// just create a single node
@@ -381,20 +578,56 @@ func (p *Platform) GetNodes() ([]platform.Node, error) {
 
 // CreateProject will create a new project
 func (p *Platform) CreateProject(createProjectOptions *platform.CreateProjectOptions) error {
-	return p.localStore.createOrUpdateProject(&createProjectOptions.ProjectConfig)
+	existingProjects, err := p.localStore.getProjects(&createProjectOptions.ProjectConfig.Meta)
+	if err != nil {
+		return errdefs.NewSystem(errors.Wrap(err, "Failed to check for an existing project"))
+	}
+
+	if len(existingProjects) > 0 {
+		return errdefs.NewConflict(errors.Errorf("Project %s already exists",
+			createProjectOptions.ProjectConfig.Meta.Name))
+	}
+
+	if err := p.localStore.createOrUpdateProject(&createProjectOptions.ProjectConfig); err != nil {
+		return errdefs.NewSystem(errors.Wrap(err, "Failed to create project"))
+	}
+
+	p.publishEvent(events.ReasonProjectCreated,
+		createProjectOptions.ProjectConfig.Meta.Namespace, createProjectOptions.ProjectConfig.Meta.Name)
+
+	return nil
 }
 
 // UpdateProject will update an existing project
 func (p *Platform) UpdateProject(updateProjectOptions *platform.UpdateProjectOptions) error {
-	return p.localStore.createOrUpdateProject(&updateProjectOptions.ProjectConfig)
+	if err := p.localStore.createOrUpdateProject(&updateProjectOptions.ProjectConfig); err != nil {
+		if err == nuclio.ErrNotFound || errdefs.IsNotFound(err) {
+			return errdefs.NewNotFound(err)
+		}
+
+		return errdefs.NewSystem(errors.Wrap(err, "Failed to update project"))
+	}
+
+	return nil
 }
 
 // DeleteProject will delete an existing project
 func (p *Platform) DeleteProject(deleteProjectOptions *platform.DeleteProjectOptions) error {
 	if err := p.Platform.ValidateDeleteProjectOptions(deleteProjectOptions); err != nil {
-		return errors.Wrap(err, "Delete project options validation failed")
+		return errdefs.NewInvalidParameter(errors.Wrap(err, "Delete project options validation failed"))
+	}
+
+	if err := p.localStore.deleteProject(&deleteProjectOptions.Meta); err != nil {
+		if err == nuclio.ErrNotFound || errdefs.IsNotFound(err) {
+			return errdefs.NewNotFound(err)
+		}
+
+		return errdefs.NewSystem(errors.Wrap(err, "Failed to delete project"))
 	}
-	return p.localStore.deleteProject(&deleteProjectOptions.Meta)
+
+	p.publishEvent(events.ReasonProjectDeleted, deleteProjectOptions.Meta.Namespace, deleteProjectOptions.Meta.Name)
+
+	return nil
 }
 
 // GetProjects will list existing projects
@@ -405,17 +638,54 @@ func (p *Platform) GetProjects(getProjectsOptions *platform.GetProjectsOptions)
 // CreateFunctionEvent will create a new function event that can later be used as a template from
 // which to invoke functions
 func (p *Platform) CreateFunctionEvent(createFunctionEventOptions *platform.CreateFunctionEventOptions) error {
-	return p.localStore.createOrUpdateFunctionEvent(&createFunctionEventOptions.FunctionEventConfig)
+	existingFunctionEvents, err := p.localStore.getFunctionEvents(&createFunctionEventOptions.FunctionEventConfig.Meta)
+	if err != nil {
+		return errdefs.NewSystem(errors.Wrap(err, "Failed to check for an existing function event"))
+	}
+
+	if len(existingFunctionEvents) > 0 {
+		return errdefs.NewConflict(errors.Errorf("Function event %s already exists",
+			createFunctionEventOptions.FunctionEventConfig.Meta.Name))
+	}
+
+	if err := p.localStore.createOrUpdateFunctionEvent(&createFunctionEventOptions.FunctionEventConfig); err != nil {
+		return errdefs.NewSystem(errors.Wrap(err, "Failed to create function event"))
+	}
+
+	p.publishEvent(events.ReasonFunctionEventCreated,
+		createFunctionEventOptions.FunctionEventConfig.Meta.Namespace,
+		createFunctionEventOptions.FunctionEventConfig.Meta.Name)
+
+	return nil
 }
 
 // UpdateFunctionEvent will update a previously existing function event
 func (p *Platform) UpdateFunctionEvent(updateFunctionEventOptions *platform.UpdateFunctionEventOptions) error {
-	return p.localStore.createOrUpdateFunctionEvent(&updateFunctionEventOptions.FunctionEventConfig)
+	if err := p.localStore.createOrUpdateFunctionEvent(&updateFunctionEventOptions.FunctionEventConfig); err != nil {
+		if err == nuclio.ErrNotFound || errdefs.IsNotFound(err) {
+			return errdefs.NewNotFound(err)
+		}
+
+		return errdefs.NewSystem(errors.Wrap(err, "Failed to update function event"))
+	}
+
+	return nil
 }
 
 // DeleteFunctionEvent will delete a previously existing function event
 func (p *Platform) DeleteFunctionEvent(deleteFunctionEventOptions *platform.DeleteFunctionEventOptions) error {
-	return p.localStore.deleteFunctionEvent(&deleteFunctionEventOptions.Meta)
+	if err := p.localStore.deleteFunctionEvent(&deleteFunctionEventOptions.Meta); err != nil {
+		if err == nuclio.ErrNotFound || errdefs.IsNotFound(err) {
+			return errdefs.NewNotFound(err)
+		}
+
+		return errdefs.NewSystem(errors.Wrap(err, "Failed to delete function event"))
+	}
+
+	p.publishEvent(events.ReasonFunctionEventDeleted,
+		deleteFunctionEventOptions.Meta.Namespace, deleteFunctionEventOptions.Meta.Name)
+
+	return nil
 }
 
 // GetFunctionEvents will list existing function events
@@ -468,7 +738,7 @@ func (p *Platform) GetNamespaces() ([]string, error) {
 }
 
 func (p *Platform) GetDefaultInvokeIPAddresses() ([]string, error) {
-	return []string{"172.17.0.1"}, nil
+	return p.containerRuntime.GetDefaultInvokeIPAddresses()
 }
 
 func (p *Platform) getFreeLocalPort() (int, error) {
@@ -489,6 +759,10 @@ func (p *Platform) getFreeLocalPort() (int, error) {
 func (p *Platform) deployFunction(createFunctionOptions *platform.CreateFunctionOptions,
 	previousHTTPPort int) (*platform.CreateFunctionResult, error) {
 
+	if err := p.pullFromRunRegistryIfMissing(createFunctionOptions); err != nil {
+		return nil, errors.Wrap(err, "Failed to pull image from run registry")
+	}
+
 	// get function platform specific configuration
 	functionPlatformConfiguration, err := newFunctionPlatformConfiguration(&createFunctionOptions.FunctionConfig)
 	if err != nil {
@@ -545,8 +819,10 @@ func (p *Platform) deployFunction(createFunctionOptions *platform.CreateFunction
 		envMap[env.Name] = env.Value
 	}
 
-	// run the docker image
-	containerID, err := p.dockerClient.RunContainer(createFunctionOptions.FunctionConfig.Spec.Image, &dockerclient.RunOptions{
+	// run the function container through the selected container runtime (docker or
+	// podman)
+	containerID, err := p.containerRuntime.Run(&containerruntime.RunOptions{
+		Image:         createFunctionOptions.FunctionConfig.Spec.Image,
 		ContainerName: p.getContainerNameByCreateFunctionOptions(createFunctionOptions),
 		Ports:         map[int]int{functionHTTPPort: 8080},
 		Env:           envMap,
@@ -556,7 +832,7 @@ func (p *Platform) deployFunction(createFunctionOptions *platform.CreateFunction
 	})
 
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to run docker container")
+		return nil, errors.Wrap(err, "Failed to run function container")
 	}
 
 	p.Logger.InfoWith("Waiting for function to be ready", "timeout", createFunctionOptions.FunctionConfig.Spec.ReadinessTimeoutSeconds)
@@ -568,11 +844,11 @@ func (p *Platform) deployFunction(createFunctionOptions *platform.CreateFunction
 		readinessTimeout = 60 * time.Second
 	}
 
-	if err = p.dockerClient.AwaitContainerHealth(containerID, &readinessTimeout); err != nil {
+	if err = p.containerRuntime.AwaitHealth(containerID, &readinessTimeout); err != nil {
 		var errMessage string
 
 		// try to get error logs
-		containerLogs, getContainerLogsErr := p.dockerClient.GetContainerLogs(containerID)
+		containerLogs, getContainerLogsErr := p.containerRuntime.Logs(containerID)
 		if getContainerLogsErr == nil {
 			errMessage = fmt.Sprintf("Function wasn't ready in time. Logs:\n%s", containerLogs)
 		} else {
@@ -633,6 +909,50 @@ func (p *Platform) encodeFunctionSpec(spec *functionconfig.Spec) string {
 	return string(encodedFunctionSpec)
 }
 
+// pullFromRunRegistryIfMissing pulls the function's image from Spec.RunRegistry if
+// it's not already present locally, resolving auth from credstore the way
+// podman/skopeo do. It is a no-op when RunRegistry isn't set
+func (p *Platform) pullFromRunRegistryIfMissing(createFunctionOptions *platform.CreateFunctionOptions) error {
+	runRegistry := createFunctionOptions.FunctionConfig.Spec.RunRegistry
+	if runRegistry == "" {
+		return nil
+	}
+
+	image := createFunctionOptions.FunctionConfig.Spec.Image
+	registryImage := fmt.Sprintf("%s/%s", runRegistry, image)
+
+	exists, err := p.containerRuntime.ImageExists(registryImage)
+	if err != nil {
+		return errors.Wrap(err, "Failed to check whether image already exists locally")
+	}
+
+	if exists {
+		createFunctionOptions.FunctionConfig.Spec.Image = registryImage
+		return nil
+	}
+
+	credentials, err := credstore.Resolve(runRegistry)
+	if err != nil {
+		return errors.Wrap(err, "Failed to resolve run registry credentials")
+	}
+
+	p.Logger.InfoWith("Pulling image from run registry", "registry", runRegistry, "image", registryImage)
+
+	pullOptions := &containerruntime.PullOptions{}
+	if credentials != nil {
+		pullOptions.Username = credentials.Username
+		pullOptions.Password = credentials.Password
+	}
+
+	if err := p.containerRuntime.Pull(registryImage, pullOptions); err != nil {
+		return errors.Wrap(err, "Failed to pull image from run registry")
+	}
+
+	createFunctionOptions.FunctionConfig.Spec.Image = registryImage
+
+	return nil
+}
+
 func (p *Platform) getFunctionHTTPPort(createFunctionOptions *platform.CreateFunctionOptions,
 	previousHTTPPort int) (int, error) {
 
@@ -667,15 +987,14 @@ func (p *Platform) getContainerNameByCreateFunctionOptions(createFunctionOptions
 		createFunctionOptions.FunctionConfig.Meta.Name)
 }
 
-func (p *Platform) getContainerHTTPTriggerPort(container *dockerclient.Container) int {
-	ports := container.HostConfig.PortBindings["8080/tcp"]
-	if len(ports) == 0 {
-		return 0
+func (p *Platform) getContainerHTTPTriggerPort(container *containerruntime.Container) int {
+	for hostPort, containerPort := range container.Ports {
+		if containerPort == 8080 {
+			return hostPort
+		}
 	}
 
-	httpPort, _ := strconv.Atoi(ports[0].HostPort)
-
-	return httpPort
+	return 0
 }
 
 func (p *Platform) marshallAnnotations(annotations map[string]string) []byte {
@@ -693,19 +1012,23 @@ func (p *Platform) marshallAnnotations(annotations map[string]string) []byte {
 }
 
 func (p *Platform) deletePreviousContainers(createFunctionOptions *platform.CreateFunctionOptions) (int, error) {
+	if p.ctx.Err() != nil {
+		return 0, errdefs.NewUnavailable(errors.New("Platform is shutting down, refusing to delete containers"))
+	}
+
 	var previousHTTPPort int
 
 	createFunctionOptions.Logger.InfoWith("Cleaning up before deployment")
 
-	getContainerOptions := &dockerclient.GetContainerOptions{
+	getContainerOptions := &containerruntime.GetContainerOptions{
 		Name:    p.getContainerNameByCreateFunctionOptions(createFunctionOptions),
 		Stopped: true,
 	}
 
-	containers, err := p.dockerClient.GetContainers(getContainerOptions)
+	containers, err := p.containerRuntime.GetContainers(getContainerOptions)
 
 	if err != nil {
-		return 0, errors.Wrap(err, "Failed to get function")
+		return 0, errdefs.NewSystem(errors.Wrap(err, "Failed to get function"))
 	}
 
 	// if the function exists, delete it
@@ -716,9 +1039,9 @@ func (p *Platform) deletePreviousContainers(createFunctionOptions *platform.Crea
 		for _, container := range containers {
 			previousHTTPPort = p.getContainerHTTPTriggerPort(&container)
 
-			err = p.dockerClient.RemoveContainer(container.Name)
+			err = p.containerRuntime.Remove(container.Name)
 			if err != nil {
-				return 0, errors.Wrap(err, "Failed to delete existing function")
+				return 0, errdefs.NewSystem(errors.Wrap(err, "Failed to delete existing function"))
 			}
 		}
 	}
@@ -726,7 +1049,10 @@ func (p *Platform) deletePreviousContainers(createFunctionOptions *platform.Crea
 	return previousHTTPPort, nil
 }
 
-func (p *Platform) ValidateFunctionContainersHealthiness() {
+// ValidateFunctionContainersHealthiness checks every eligible function's container
+// health and acts on the result (see markFunctionUnhealthy). It aborts as soon as ctx
+// is done, so a Shutdown in progress doesn't keep probing or recreating containers
+func (p *Platform) ValidateFunctionContainersHealthiness(ctx context.Context) {
 	namespaces, err := p.GetNamespaces()
 	if err != nil {
 		p.Logger.WarnWith("Cannot not get namespaces", "err", err)
@@ -735,6 +1061,10 @@ func (p *Platform) ValidateFunctionContainersHealthiness() {
 	var unhealthyFunctions []*functionconfig.Config
 	var functionsFailedToMarkUnhealthy []*functionconfig.Config
 	for _, namespace := range namespaces {
+		if ctx.Err() != nil {
+			p.Logger.Debug("Healthiness validator shutting down, aborting remaining namespaces")
+			return
+		}
 
 		// get functions for that namespace
 		functions, err := p.GetFunctions(&platform.GetFunctionsOptions{
@@ -749,14 +1079,22 @@ func (p *Platform) ValidateFunctionContainersHealthiness() {
 
 		// For each function, we will check if its container is healthy
 		// in case it is not healthy (or container is missing), update function status
-		// and mark its state to error
+		// and mark its state to error. Functions already classified as Healthy are
+		// re-checked too, so they don't fall out of the polling loop once they leave
+		// FunctionStateReady
 		for _, function := range functions {
+			if ctx.Err() != nil {
+				p.Logger.Debug("Healthiness validator shutting down, aborting remaining functions")
+				return
+			}
+
 			functionConfig := function.GetConfig()
 			functionState := function.GetStatus().State
 			functionName := functionConfig.Meta.Name
-			if functionState != functionconfig.FunctionStateReady {
+			if functionState != functionconfig.FunctionStateReady &&
+				functionState != functionconfig.FunctionStateHealthy {
 
-				// Skipping checking of not-ready functions
+				// Skipping checking of not-ready/not-healthy functions
 				continue
 			}
 
@@ -770,11 +1108,15 @@ func (p *Platform) ValidateFunctionContainersHealthiness() {
 				},
 			})
 
-			if err := p.markFunctionUnhealthy(containerID, functionConfig); err != nil {
-				functionsFailedToMarkUnhealthy = append(functionsFailedToMarkUnhealthy, functionConfig)
-			} else {
-				unhealthyFunctions = append(unhealthyFunctions, functionConfig)
-
+			// a nil error means the function is healthy (or was recreated successfully);
+			// an Unhealthy error means it was correctly classified and marked unhealthy;
+			// any other error means the check itself failed (e.g. docker is unreachable)
+			if err := p.markFunctionUnhealthy(ctx, containerID, functionConfig); err != nil {
+				if errdefs.IsUnhealthy(err) {
+					unhealthyFunctions = append(unhealthyFunctions, functionConfig)
+				} else {
+					functionsFailedToMarkUnhealthy = append(functionsFailedToMarkUnhealthy, functionConfig)
+				}
 			}
 		}
 	}
@@ -791,19 +1133,170 @@ func (p *Platform) ValidateFunctionContainersHealthiness() {
 	}
 }
 
-func (p *Platform) markFunctionUnhealthy(containerID string, functionConfig *functionconfig.Config) error {
+// markFunctionUnhealthy checks containerID's health and, if unhealthy, records the
+// result in the function's HealthCheckLog and either restarts it (if the failure
+// streak is still within MaxRestartRetries) or gives up and transitions it to
+// FunctionStateError permanently
+func (p *Platform) markFunctionUnhealthy(
+	ctx context.Context, containerID string, functionConfig *functionconfig.Config) error {
+	functionID := functionConfig.Meta.GetUniqueID()
 
-	if err := p.dockerClient.AwaitContainerHealth(containerID,
-		&p.functionContainersHealthinessTimeout); err != nil {
+	dockerHealthStatus, err := p.containerRuntime.GetContainerHealthStatus(containerID)
+	if err != nil {
+		return errdefs.NewSystem(errors.Wrap(err, "Failed to get container health status"))
+	}
 
-		// function container is not healthy or missing, mark function state as error
-		return p.localStore.createOrUpdateFunction(&functionconfig.ConfigWithStatus{
-			Config: *functionConfig,
-			Status: functionconfig.Status{
-				State:   functionconfig.FunctionStateError,
-				Message: "Container is not healthy",
-			},
-		})
+	functionState := functionconfig.DockerHealthStatusToFunctionState(dockerHealthStatus)
+
+	switch functionState {
+	case functionconfig.FunctionStateHealthy, functionconfig.FunctionStateNoHealthcheck:
+		p.healthLogs.record(functionID, HealthCheckLogEntry{Timestamp: time.Now(), Healthy: true})
+		return p.updateFunctionState(functionConfig, functionState, "")
+
+	case functionconfig.FunctionStateStarting:
+
+		// container hasn't had time to report health yet - nothing to do
+		return nil
 	}
+
+	// functionState == FunctionStateUnhealthy beyond this point
+	streak := p.healthLogs.record(functionID, HealthCheckLogEntry{
+		Timestamp:     time.Now(),
+		Healthy:       false,
+		StderrSnippet: "container health check reports unhealthy",
+	})
+
+	if streak <= p.maxRestartRetries {
+		if err := p.updateFunctionState(functionConfig, functionconfig.FunctionStateUnhealthy, ""); err != nil {
+			p.Logger.WarnWith("Failed to persist unhealthy state", "name", functionConfig.Meta.Name, "err", err)
+		}
+
+		attempt := p.healthLogs.nextRestartAttempt(functionID)
+		backoff := restartBackoff(attempt)
+
+		p.Logger.WarnWith("Function container unhealthy, attempting restart",
+			"name", functionConfig.Meta.Name,
+			"streak", streak,
+			"attempt", attempt,
+			"backoff", backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+
+			// shutting down - don't block the rest of the pass on this backoff
+			return errdefs.NewUnavailable(ctx.Err())
+		}
+
+		recreateErr := p.recreateFunctionContainer(functionConfig)
+		if recreateErr == nil {
+			return nil
+		}
+
+		p.Logger.WarnWith("Failed to recreate unhealthy function container",
+			"name", functionConfig.Meta.Name, "err", recreateErr)
+
+		return errdefs.NewUnhealthy(recreateErr)
+	}
+
+	// out of retries - give up and mark permanently unhealthy
+	p.publishFunctionEvent(events.ReasonContainerHealthLost, functionConfig.Meta, "", "", 0)
+
+	if err := p.updateFunctionState(functionConfig, functionconfig.FunctionStateError, "Container is not healthy"); err != nil {
+		return errdefs.NewSystem(err)
+	}
+
+	return errdefs.NewUnhealthy(errors.New("Function container is not healthy and ran out of restart retries"))
+}
+
+// updateFunctionState persists functionConfig's status with the given state and message
+func (p *Platform) updateFunctionState(
+	functionConfig *functionconfig.Config, state functionconfig.FunctionState, message string) error {
+	return p.localStore.createOrUpdateFunction(&functionconfig.ConfigWithStatus{
+		Config: *functionConfig,
+		Status: functionconfig.Status{
+			State:   state,
+			Message: message,
+		},
+	})
+}
+
+// recreateFunctionContainer re-deploys functionConfig through CreateFunction. The
+// previous port is not passed along: it is reused only when the old container still
+// exists (deletePreviousContainers reads it from that container's port bindings);
+// otherwise the configured HTTP port or a free local port is used
+func (p *Platform) recreateFunctionContainer(functionConfig *functionconfig.Config) error {
+	existingFunctions, err := p.localStore.getFunctions(&functionConfig.Meta)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get existing function config")
+	}
+
+	if len(existingFunctions) == 0 {
+		return errors.New("No existing function config to recreate from")
+	}
+
+	previousStatus := existingFunctions[0].GetStatus()
+
+	createFunctionOptions := &platform.CreateFunctionOptions{
+		Logger:         p.Logger,
+		FunctionConfig: *functionConfig,
+	}
+
+	if _, err := p.CreateFunction(createFunctionOptions); err != nil {
+		return errors.Wrap(err, "Failed to recreate function container")
+	}
+
+	p.publishFunctionEvent(events.ReasonContainerHealthRestored, functionConfig.Meta, "", "", 0)
+	p.Logger.InfoWith("Recreated unhealthy function container",
+		"name", functionConfig.Meta.Name, "previousPort", previousStatus.HTTPPort)
+
 	return nil
 }
+
+// GetFunctionHealthHistory returns the recorded health check history for the given
+// function, oldest entry first
+func (p *Platform) GetFunctionHealthHistory(name string, namespace string) []HealthCheckLogEntry {
+	functionID := (&functionconfig.Meta{Name: name, Namespace: namespace}).GetUniqueID()
+
+	return p.healthLogs.get(functionID)
+}
+
+// publishFunctionEvent emits a lifecycle event for functionMeta. image, containerID,
+// and httpPort may be left empty/zero for events that precede a build or a running
+// container (e.g. FunctionCreating)
+func (p *Platform) publishFunctionEvent(reason events.Reason,
+	functionMeta functionconfig.Meta, image string, containerID string, httpPort int) {
+	if p.eventer == nil {
+		return
+	}
+
+	p.eventer.Publish(events.Event{
+		Timestamp:   time.Now().Unix(),
+		Reason:      reason,
+		Namespace:   functionMeta.Namespace,
+		Name:        functionMeta.Name,
+		Image:       image,
+		ContainerID: containerID,
+		HTTPPort:    httpPort,
+	})
+}
+
+// publishEvent emits a lifecycle event that isn't a function's (project or function
+// event CRUD), which have no image/container/port to report
+func (p *Platform) publishEvent(reason events.Reason, namespace string, name string) {
+	if p.eventer == nil {
+		return
+	}
+
+	p.eventer.Publish(events.Event{
+		Timestamp: time.Now().Unix(),
+		Reason:    reason,
+		Namespace: namespace,
+		Name:      name,
+	})
+}
+
+// GetEvents streams lifecycle events matching filter until ctx is done
+func (p *Platform) GetEvents(ctx context.Context, filter *events.Filter) <-chan events.Event {
+	return p.eventer.GetEvents(ctx, filter)
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/nuclio/logger"
+)
+
+// entry is a single scheduled job sitting in the min-heap, ordered by nextFireAt
+type entry struct {
+	job        *Job
+	nextFireAt time.Time
+	index      int
+}
+
+type entryHeap []*entry
+
+func (h entryHeap) Len() int            { return len(h) }
+func (h entryHeap) Less(i, j int) bool  { return h[i].nextFireAt.Before(h[j].nextFireAt) }
+func (h entryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *entryHeap) Push(x interface{}) {
+	e := x.(*entry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	*h = old[:n-1]
+	return e
+}
+
+// heapScheduler is the default Scheduler implementation: a min-heap of next-fire
+// timestamps, wound by a single goroutine that sleeps until the earliest job is due
+type heapScheduler struct {
+	logger   logger.Logger
+	lock     sync.Mutex
+	entries  entryHeap
+	byID     map[string]*entry
+	wake     chan struct{}
+	stopChan chan struct{}
+}
+
+// New creates the default heap-backed Scheduler
+func New(parentLogger logger.Logger) Scheduler {
+	s := &heapScheduler{
+		logger:   parentLogger.GetChild("scheduler"),
+		byID:     map[string]*entry{},
+		wake:     make(chan struct{}, 1),
+		stopChan: make(chan struct{}),
+	}
+
+	go s.run()
+
+	return s
+}
+
+// Add registers a job under jobID, replacing any existing job with that ID
+func (s *heapScheduler) Add(jobID string, job Job) {
+	job.ID = jobID
+
+	s.lock.Lock()
+	if existing, found := s.byID[jobID]; found {
+		heap.Remove(&s.entries, existing.index)
+	}
+
+	e := &entry{
+		job:        &job,
+		nextFireAt: job.Schedule.Next(time.Now()),
+	}
+	heap.Push(&s.entries, e)
+	s.byID[jobID] = e
+	s.lock.Unlock()
+
+	s.poke()
+}
+
+// Remove unregisters the job with the given ID, if any
+func (s *heapScheduler) Remove(jobID string) {
+	s.lock.Lock()
+	if existing, found := s.byID[jobID]; found {
+		heap.Remove(&s.entries, existing.index)
+		delete(s.byID, jobID)
+	}
+	s.lock.Unlock()
+
+	s.poke()
+}
+
+// List returns the IDs of all currently registered jobs
+func (s *heapScheduler) List() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	jobIDs := make([]string, 0, len(s.byID))
+	for jobID := range s.byID {
+		jobIDs = append(jobIDs, jobID)
+	}
+
+	return jobIDs
+}
+
+// Stop stops the scheduler's background goroutine
+func (s *heapScheduler) Stop() {
+	close(s.stopChan)
+}
+
+// poke wakes the run loop so it can recompute how long to sleep
+func (s *heapScheduler) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *heapScheduler) run() {
+	for {
+		sleepDuration := s.nextSleepDuration()
+
+		var timer *time.Timer
+		if sleepDuration >= 0 {
+			timer = time.NewTimer(sleepDuration)
+		}
+
+		var timerChan <-chan time.Time
+		if timer != nil {
+			timerChan = timer.C
+		}
+
+		select {
+		case <-timerChan:
+			s.fireDue()
+		case <-s.wake:
+		case <-s.stopChan:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// nextSleepDuration returns how long to sleep until the earliest job is due, or -1 if
+// there are no jobs registered (in which case run() blocks on wake/stopChan only)
+func (s *heapScheduler) nextSleepDuration() time.Duration {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.entries) == 0 {
+		return -1
+	}
+
+	// an overdue job fires right away rather than being mistaken for "no jobs"
+	if untilNextFire := time.Until(s.entries[0].nextFireAt); untilNextFire > 0 {
+		return untilNextFire
+	}
+
+	return 0
+}
+
+// fireDue pops and invokes every job whose nextFireAt has passed, then reschedules it
+func (s *heapScheduler) fireDue() {
+	now := time.Now()
+
+	for {
+		s.lock.Lock()
+		if len(s.entries) == 0 || s.entries[0].nextFireAt.After(now) {
+			s.lock.Unlock()
+			return
+		}
+
+		e := s.entries[0]
+
+		if e.job.Coalesce && e.job.running {
+
+			// previous run still executing - push this fire out to the next slot
+			e.nextFireAt = e.job.Schedule.Next(now)
+			heap.Fix(&s.entries, e.index)
+			s.lock.Unlock()
+			continue
+		}
+
+		e.job.running = true
+		s.lock.Unlock()
+
+		jitter := jitterFor(e.job.Jitter)
+		go s.invoke(e.job, jitter)
+
+		s.lock.Lock()
+		e.nextFireAt = e.job.Schedule.Next(now)
+		heap.Fix(&s.entries, e.index)
+		s.lock.Unlock()
+	}
+}
+
+func (s *heapScheduler) invoke(job *Job, jitter time.Duration) {
+	if jitter > 0 {
+		time.Sleep(jitter)
+	}
+
+	defer func() {
+		s.lock.Lock()
+		job.running = false
+		s.lock.Unlock()
+	}()
+
+	job.Invoke(context.Background())
+}
+
+func jitterFor(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(max)))
+}
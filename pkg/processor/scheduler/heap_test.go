@@ -0,0 +1,77 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type HeapSchedulerTestSuite struct {
+	suite.Suite
+}
+
+// everyInterval fires every Interval after "after", used in place of a real cron
+// schedule to drive the scheduler at a predictable rate in tests
+type everyInterval struct {
+	Interval time.Duration
+}
+
+func (e everyInterval) Next(after time.Time) time.Time {
+	return after.Add(e.Interval)
+}
+
+// TestCoalescingJobRunningFieldUnderRace exercises fireDue and invoke concurrently
+// under -race: fireDue reads/writes job.running under s.lock, while the goroutine
+// spawned for an invocation clears it in a deferred cleanup. Both accesses must go
+// through the same lock or this fails under `go test -race`
+func (suite *HeapSchedulerTestSuite) TestCoalescingJobRunningFieldUnderRace() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	s := New(logger)
+	defer s.Stop()
+
+	var invocations int64
+
+	s.Add("coalesced-job", Job{
+		Schedule: everyInterval{Interval: time.Millisecond},
+		Coalesce: true,
+		Invoke: func(ctx context.Context) {
+			atomic.AddInt64(&invocations, 1)
+
+			// hold the "running" state for long enough that several fireDue
+			// passes overlap it, so a data race on job.running would actually
+			// be exercised rather than just theoretically possible
+			time.Sleep(20 * time.Millisecond)
+		},
+	})
+
+	// let several fire cycles happen while the first invocation is still "running"
+	time.Sleep(100 * time.Millisecond)
+
+	suite.Require().True(atomic.LoadInt64(&invocations) >= 1)
+}
+
+func TestHeapSchedulerTestSuite(t *testing.T) {
+	suite.Run(t, new(HeapSchedulerTestSuite))
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"time"
+
+	"github.com/nuclio/nuclio/pkg/errors"
+	"github.com/robfig/cron"
+)
+
+// FixedInterval is a Schedule that fires every Interval
+type FixedInterval struct {
+	Interval time.Duration
+}
+
+// Next returns the next fire time strictly after "after"
+func (f FixedInterval) Next(after time.Time) time.Time {
+	return after.Add(f.Interval)
+}
+
+// CronSchedule is a Schedule backed by a standard five-field cron expression
+type CronSchedule struct {
+	expression cron.Schedule
+}
+
+// NewCronSchedule parses a standard five-field cron expression (e.g. "*/5 * * * *")
+// into a Schedule
+func NewCronSchedule(spec string) (*CronSchedule, error) {
+	expression, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse cron expression: %s", spec)
+	}
+
+	return &CronSchedule{expression: expression}, nil
+}
+
+// Next returns the next fire time strictly after "after"
+func (c *CronSchedule) Next(after time.Time) time.Time {
+	return c.expression.Next(after)
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scheduler provides a single shared task scheduler that triggers (cron,
+// and anything else with a notion of "fire at time X") can register jobs into,
+// instead of each trigger spinning its own timer goroutine
+package scheduler
+
+import (
+	"context"
+	"time"
+)
+
+// Schedule describes when a job should next fire
+type Schedule interface {
+
+	// Next returns the next fire time strictly after "after"
+	Next(after time.Time) time.Time
+}
+
+// Job is a single scheduled unit of work
+type Job struct {
+	ID       string
+	Schedule Schedule
+	Invoke   func(ctx context.Context)
+
+	// Jitter adds up to this much random delay to each fire, to avoid thundering
+	// herds when many jobs share the same schedule
+	Jitter time.Duration
+
+	// Coalesce, when true, skips a fire if the previous invocation of this job is
+	// still executing rather than running invocations concurrently
+	Coalesce bool
+
+	running bool
+}
+
+// Scheduler is a single place to register, remove and list scheduled jobs. A
+// default implementation is provided by New
+type Scheduler interface {
+
+	// Add registers a job under jobID, replacing any existing job with that ID
+	Add(jobID string, job Job)
+
+	// Remove unregisters the job with the given ID, if any
+	Remove(jobID string)
+
+	// List returns the IDs of all currently registered jobs
+	List() []string
+
+	// Stop stops the scheduler's background goroutine. Jobs already invoked continue
+	// to run to completion
+	Stop()
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package worker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nuclio/logger"
+)
+
+// ErrWorkerDeadlineExceeded is returned to the in-flight invocation's caller when a
+// worker's invocation deadline is reached before the runtime call completed
+var ErrWorkerDeadlineExceeded = errors.New("Worker deadline exceeded")
+
+// ErrWorkerStuckThresholdExceeded is returned to the in-flight invocation's caller
+// when the background sweeper force-restarts a worker held past StuckWorkerThreshold
+var ErrWorkerStuckThresholdExceeded = errors.New("Worker stuck threshold exceeded")
+
+// Cancelable is implemented by workers whose in-flight invocation can be cancelled
+// mid-flight (e.g. over the shim's control channel) and whose state can be forced
+// into "dirty" so the next allocation restarts the underlying runtime rather than
+// reusing a worker that may still be running stale user code
+type Cancelable interface {
+
+	// CancelInvocation cancels the current in-flight invocation, if any, causing it
+	// to return cancelErr to whatever is waiting on it
+	CancelInvocation(cancelErr error)
+
+	// MarkDirty forces the worker to be considered dirty, so it is restarted rather
+	// than reused
+	MarkDirty()
+}
+
+// DeadlineWorker decorates an Allocator so that every allocation is stamped with a
+// hard deadline. If the deadline is reached before the caller releases the worker,
+// the in-flight invocation is cancelled, the worker is marked dirty, and a background
+// sweeper can force-restart workers that have been held past StuckWorkerThreshold
+type DeadlineWorker struct {
+	logger logger.Logger
+	Allocator
+
+	lock           sync.Mutex
+	allocatedAt    map[*Worker]time.Time
+	maxLatency     time.Duration
+	stuckThreshold time.Duration
+
+	stuckTotal  uint64
+	killedTotal uint64
+
+	stopChan chan struct{}
+}
+
+// NewDeadlineWorkerAllocator wraps allocator so every allocation is stamped with a
+// deadline of maxLatency, and workers held past stuckThreshold are force-restarted by
+// a background sweeper that scans GetWorkers() every sweepInterval
+func NewDeadlineWorkerAllocator(parentLogger logger.Logger,
+	allocator Allocator,
+	maxLatency time.Duration,
+	stuckThreshold time.Duration,
+	sweepInterval time.Duration) *DeadlineWorker {
+
+	dw := &DeadlineWorker{
+		logger:         parentLogger.GetChild("deadline_allocator"),
+		Allocator:      allocator,
+		allocatedAt:    map[*Worker]time.Time{},
+		maxLatency:     maxLatency,
+		stuckThreshold: stuckThreshold,
+		stopChan:       make(chan struct{}),
+	}
+
+	go dw.sweep(sweepInterval)
+
+	return dw
+}
+
+// Allocate allocates a worker and stamps it with the configured deadline
+func (dw *DeadlineWorker) Allocate(timeout time.Duration) (*Worker, error) {
+	workerInstance, err := dw.Allocator.Allocate(timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	allocatedAt := time.Now()
+
+	dw.lock.Lock()
+	dw.allocatedAt[workerInstance] = allocatedAt
+	dw.lock.Unlock()
+
+	if dw.maxLatency > 0 {
+		time.AfterFunc(dw.maxLatency, func() {
+			dw.onDeadlineExceeded(workerInstance, allocatedAt)
+		})
+	}
+
+	return workerInstance, nil
+}
+
+// Release clears the deadline bookkeeping for the worker and releases it upstream
+func (dw *DeadlineWorker) Release(workerInstance *Worker) {
+	dw.lock.Lock()
+	delete(dw.allocatedAt, workerInstance)
+	dw.lock.Unlock()
+
+	dw.Allocator.Release(workerInstance)
+}
+
+func (dw *DeadlineWorker) onDeadlineExceeded(workerInstance *Worker, stampedAt time.Time) {
+	dw.lock.Lock()
+	allocatedAt, stillAllocated := dw.allocatedAt[workerInstance]
+	dw.lock.Unlock()
+
+	if !stillAllocated || !allocatedAt.Equal(stampedAt) {
+
+		// the worker was released before its deadline fired (and possibly allocated
+		// again since, under a deadline of its own) - nothing to do
+		return
+	}
+
+	dw.logger.WarnWith("Worker exceeded deadline, cancelling invocation",
+		"allocatedAt", allocatedAt,
+		"maxLatency", dw.maxLatency)
+
+	dw.killedTotal++
+
+	if cancelable, ok := interface{}(workerInstance).(Cancelable); ok {
+		cancelable.CancelInvocation(ErrWorkerDeadlineExceeded)
+		cancelable.MarkDirty()
+	}
+}
+
+// sweep force-restarts any worker that has been allocated longer than stuckThreshold,
+// guarding against a runaway handler permanently holding a slot
+func (dw *DeadlineWorker) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			dw.sweepOnce()
+		case <-dw.stopChan:
+			return
+		}
+	}
+}
+
+func (dw *DeadlineWorker) sweepOnce() {
+	dw.lock.Lock()
+	var stuck []*Worker
+	for workerInstance, allocatedAt := range dw.allocatedAt {
+		if time.Since(allocatedAt) > dw.stuckThreshold {
+			stuck = append(stuck, workerInstance)
+		}
+	}
+	dw.lock.Unlock()
+
+	for _, workerInstance := range stuck {
+		dw.logger.WarnWith("Worker stuck past threshold, forcing restart", "threshold", dw.stuckThreshold)
+		dw.stuckTotal++
+
+		if cancelable, ok := interface{}(workerInstance).(Cancelable); ok {
+			cancelable.CancelInvocation(ErrWorkerStuckThresholdExceeded)
+			cancelable.MarkDirty()
+		}
+	}
+}
+
+// GetStatistics returns the underlying allocator's statistics; stuck/killed counters
+// are exposed on DeadlineWorker itself since they are not tracked by every allocator
+func (dw *DeadlineWorker) GetStatistics() *AllocatorStatistics {
+	return dw.Allocator.GetStatistics()
+}
+
+// GetStuckWorkersTotal returns the number of workers force-restarted for exceeding
+// StuckWorkerThreshold
+func (dw *DeadlineWorker) GetStuckWorkersTotal() uint64 {
+	return dw.stuckTotal
+}
+
+// GetKilledWorkersTotal returns the number of in-flight invocations cancelled for
+// exceeding their deadline
+func (dw *DeadlineWorker) GetKilledWorkersTotal() uint64 {
+	return dw.killedTotal
+}
+
+// Stop stops the background sweeper goroutine
+func (dw *DeadlineWorker) Stop() {
+	close(dw.stopChan)
+}
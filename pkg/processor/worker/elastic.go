@@ -0,0 +1,278 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package worker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nuclio/logger"
+)
+
+// WorkerFactory creates a new worker with the given id, on demand
+type WorkerFactory func(id int) (*Worker, error)
+
+// WorkerDisposer tears down a worker that is being reaped, releasing whatever
+// runtime resources (subprocesses, shim connections) it holds
+type WorkerDisposer func(worker *Worker)
+
+//
+// Elastic pool of workers
+// Starts with minWorkers and grows up to maxWorkers on demand. Idle workers beyond
+// minWorkers are reaped after IdleTimeout
+//
+
+type elasticPool struct {
+	logger     logger.Logger
+	lock       sync.Mutex
+	workerChan chan *Worker
+	workers    []*Worker
+	lastUsedAt map[int]time.Time
+	statistics AllocatorStatistics
+
+	workerFactory  WorkerFactory
+	workerDisposer WorkerDisposer
+
+	minWorkers        int
+	maxWorkers        int
+	idleCheckInterval time.Duration
+	idleTimeout       time.Duration
+
+	nextWorkerID int
+	stopChan     chan struct{}
+}
+
+// NewElasticWorkerAllocator creates an allocator that scales its worker count between
+// minWorkers and maxWorkers based on load, spawning workers on demand via workerFactory
+// and reaping idle workers (beyond minWorkers) via workerDisposer once they have been
+// idle for longer than idleTimeout
+func NewElasticWorkerAllocator(parentLogger logger.Logger,
+	minWorkers int,
+	maxWorkers int,
+	idleCheckInterval time.Duration,
+	idleTimeout time.Duration,
+	workerFactory WorkerFactory,
+	workerDisposer WorkerDisposer) (Allocator, error) {
+
+	newElasticPool := &elasticPool{
+		logger:            parentLogger.GetChild("elastic_pool_allocator"),
+		workerChan:        make(chan *Worker, maxWorkers),
+		lastUsedAt:        map[int]time.Time{},
+		workerFactory:     workerFactory,
+		workerDisposer:    workerDisposer,
+		minWorkers:        minWorkers,
+		maxWorkers:        maxWorkers,
+		idleCheckInterval: idleCheckInterval,
+		idleTimeout:       idleTimeout,
+		stopChan:          make(chan struct{}),
+	}
+
+	// start off with minWorkers live workers
+	for i := 0; i < minWorkers; i++ {
+		workerInstance, err := newElasticPool.spawnWorker()
+		if err != nil {
+			return nil, err
+		}
+
+		newElasticPool.workerChan <- workerInstance
+	}
+
+	go newElasticPool.reap()
+
+	return newElasticPool, nil
+}
+
+func (ep *elasticPool) Allocate(timeout time.Duration) (*Worker, error) {
+	ep.statistics.WorkerAllocationCount++
+	ep.statistics.WorkerAllocationWorkersAvailableTotal += uint64(len(ep.workerChan))
+
+	select {
+	case workerInstance := <-ep.workerChan:
+		ep.statistics.WorkerAllocationSuccessImmediateTotal++
+		ep.touch(workerInstance)
+		return workerInstance, nil
+	default:
+	}
+
+	// no idle worker - try to grow the pool
+	if workerInstance, err := ep.tryGrow(); err == nil {
+		ep.statistics.WorkerAllocationSuccessImmediateTotal++
+		ep.touch(workerInstance)
+		return workerInstance, nil
+	}
+
+	if timeout == 0 {
+		ep.statistics.WorkerAllocationTimeoutTotal++
+		return nil, ErrNoAvailableWorkers
+	}
+
+	waitStartAt := time.Now()
+
+	select {
+	case workerInstance := <-ep.workerChan:
+		ep.statistics.WorkerAllocationSuccessAfterWaitTotal++
+		ep.statistics.WorkerAllocationWaitDurationMilliSecondsSum += uint64(time.Since(waitStartAt).Nanoseconds() / 1e6)
+		ep.touch(workerInstance)
+		return workerInstance, nil
+	case <-time.After(timeout):
+		ep.statistics.WorkerAllocationTimeoutTotal++
+		return nil, ErrNoAvailableWorkers
+	}
+}
+
+func (ep *elasticPool) Release(worker *Worker) {
+	ep.touch(worker)
+	ep.workerChan <- worker
+}
+
+// true if the several go routines can share this allocator
+func (ep *elasticPool) Shareable() bool {
+	return true
+}
+
+// get direct access to all workers for things like management / housekeeping
+func (ep *elasticPool) GetWorkers() []*Worker {
+	ep.lock.Lock()
+	defer ep.lock.Unlock()
+
+	workers := make([]*Worker, len(ep.workers))
+	copy(workers, ep.workers)
+
+	return workers
+}
+
+// GetStatistics returns worker allocator statistics
+func (ep *elasticPool) GetStatistics() *AllocatorStatistics {
+	return &ep.statistics
+}
+
+// Stop stops the background reaper goroutine
+func (ep *elasticPool) Stop() {
+	close(ep.stopChan)
+}
+
+func (ep *elasticPool) tryGrow() (*Worker, error) {
+	ep.lock.Lock()
+	defer ep.lock.Unlock()
+
+	if len(ep.workers) >= ep.maxWorkers {
+		return nil, ErrNoAvailableWorkers
+	}
+
+	return ep.spawnWorkerLocked()
+}
+
+func (ep *elasticPool) spawnWorker() (*Worker, error) {
+	ep.lock.Lock()
+	defer ep.lock.Unlock()
+
+	return ep.spawnWorkerLocked()
+}
+
+// must be called with ep.lock held
+func (ep *elasticPool) spawnWorkerLocked() (*Worker, error) {
+	workerInstance, err := ep.workerFactory(ep.nextWorkerID)
+	if err != nil {
+		return nil, err
+	}
+
+	ep.nextWorkerID++
+	ep.workers = append(ep.workers, workerInstance)
+	ep.lastUsedAt[workerInstance.GetIndex()] = time.Now()
+	ep.statistics.WorkersSpawnedTotal++
+	ep.statistics.WorkersLiveGauge = uint64(len(ep.workers))
+
+	return workerInstance, nil
+}
+
+func (ep *elasticPool) touch(worker *Worker) {
+	ep.lock.Lock()
+	defer ep.lock.Unlock()
+
+	ep.lastUsedAt[worker.GetIndex()] = time.Now()
+}
+
+// reap periodically tears down idle workers beyond minWorkers
+func (ep *elasticPool) reap() {
+	ticker := time.NewTicker(ep.idleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			ep.reapIdleWorkers()
+		case <-ep.stopChan:
+			return
+		}
+	}
+}
+
+func (ep *elasticPool) reapIdleWorkers() {
+	for {
+		workerInstance := ep.tryReapOne()
+		if workerInstance == nil {
+			return
+		}
+
+		if ep.workerDisposer != nil {
+			ep.workerDisposer(workerInstance)
+		}
+
+		ep.statistics.WorkersReapedTotal++
+		ep.logger.DebugWith("Reaped idle worker", "workerID", workerInstance.GetIndex())
+	}
+}
+
+// tryReapOne removes and returns a single idle worker that has outlived IdleTimeout,
+// as long as doing so doesn't bring the live count below minWorkers. An idle worker
+// sitting in workerChan is never "borrowed" from an in-flight invocation, so this is safe
+func (ep *elasticPool) tryReapOne() *Worker {
+	ep.lock.Lock()
+	defer ep.lock.Unlock()
+
+	if len(ep.workers) <= ep.minWorkers {
+		return nil
+	}
+
+	select {
+	case workerInstance := <-ep.workerChan:
+		if time.Since(ep.lastUsedAt[workerInstance.GetIndex()]) < ep.idleTimeout {
+
+			// not idle long enough - put it back and stop
+			ep.workerChan <- workerInstance
+			return nil
+		}
+
+		ep.removeWorkerLocked(workerInstance)
+		return workerInstance
+	default:
+		return nil
+	}
+}
+
+// must be called with ep.lock held
+func (ep *elasticPool) removeWorkerLocked(worker *Worker) {
+	for i, workerInstance := range ep.workers {
+		if workerInstance == worker {
+			ep.workers = append(ep.workers[:i], ep.workers[i+1:]...)
+			break
+		}
+	}
+
+	delete(ep.lastUsedAt, worker.GetIndex())
+	ep.statistics.WorkersLiveGauge = uint64(len(ep.workers))
+}
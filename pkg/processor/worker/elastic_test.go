@@ -0,0 +1,113 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package worker
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type ElasticPoolTestSuite struct {
+	suite.Suite
+}
+
+// TestReapsIdleWorkersBeyondMin grows the pool past minWorkers, releases everything
+// back, and verifies the reaper brings the live count back down to minWorkers - but
+// never below it - disposing of exactly the workers it removes
+func (suite *ElasticPoolTestSuite) TestReapsIdleWorkersBeyondMin() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	var disposeLock sync.Mutex
+	disposed := map[*Worker]bool{}
+
+	allocator, err := NewElasticWorkerAllocator(logger,
+		1,
+		3,
+		5*time.Millisecond,
+		10*time.Millisecond,
+		func(id int) (*Worker, error) { return &Worker{}, nil },
+		func(worker *Worker) {
+			disposeLock.Lock()
+			defer disposeLock.Unlock()
+			disposed[worker] = true
+		})
+	suite.Require().NoError(err)
+
+	elasticAllocator, ok := allocator.(*elasticPool)
+	suite.Require().True(ok)
+	defer elasticAllocator.Stop()
+
+	// grow the pool to maxWorkers by allocating beyond the single min worker
+	workerA, err := allocator.Allocate(0)
+	suite.Require().NoError(err)
+
+	workerB, err := allocator.Allocate(0)
+	suite.Require().NoError(err)
+
+	workerC, err := allocator.Allocate(0)
+	suite.Require().NoError(err)
+
+	suite.Require().Len(allocator.GetWorkers(), 3)
+
+	// release everything - the two grown-on-demand workers should now be idle
+	allocator.Release(workerA)
+	allocator.Release(workerB)
+	allocator.Release(workerC)
+
+	// wait long enough for several reap cycles to run
+	suite.Require().Eventually(func() bool {
+		return len(allocator.GetWorkers()) == 1
+	}, time.Second, 5*time.Millisecond, "pool never shrank back to minWorkers")
+
+	disposeLock.Lock()
+	defer disposeLock.Unlock()
+	suite.Require().Len(disposed, 2)
+}
+
+// TestNeverReapsBelowMin ensures the reaper leaves minWorkers alone even though they
+// sit idle for longer than idleTimeout
+func (suite *ElasticPoolTestSuite) TestNeverReapsBelowMin() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	allocator, err := NewElasticWorkerAllocator(logger,
+		2,
+		2,
+		5*time.Millisecond,
+		5*time.Millisecond,
+		func(id int) (*Worker, error) { return &Worker{}, nil },
+		nil)
+	suite.Require().NoError(err)
+
+	elasticAllocator, ok := allocator.(*elasticPool)
+	suite.Require().True(ok)
+	defer elasticAllocator.Stop()
+
+	// give the reaper several chances to (incorrectly) shrink the pool
+	time.Sleep(50 * time.Millisecond)
+
+	suite.Require().Len(allocator.GetWorkers(), 2)
+}
+
+func TestElasticPoolTestSuite(t *testing.T) {
+	suite.Run(t, new(ElasticPoolTestSuite))
+}
@@ -0,0 +1,278 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package worker
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/nuclio/logger"
+)
+
+// HintAllocator is implemented by allocators that can take a priority hint when
+// allocating a worker. Callers should type-assert an Allocator to this interface and
+// fall back to plain Allocate when it isn't implemented, preserving backward
+// compatibility with allocators that have no notion of priority
+type HintAllocator interface {
+	AllocateWithHint(timeout time.Duration, priority string) (*Worker, error)
+}
+
+// PriorityQuota caps how many workers a given priority class may hold concurrently
+type PriorityQuota struct {
+	Priority   string
+	MaxWorkers int
+}
+
+// waiter is a single pending Allocate call, parked on its priority's queue
+type waiter struct {
+	priority string
+	index    int // position within the global readiness heap, maintained by container/heap
+	result   chan *Worker
+}
+
+// waiterHeap orders waiters by priority rank, highest priority first
+type waiterHeap struct {
+	waiters []*waiter
+	rank    map[string]int
+}
+
+func (h waiterHeap) Len() int { return len(h.waiters) }
+
+func (h waiterHeap) Less(i, j int) bool {
+	return h.rank[h.waiters[i].priority] > h.rank[h.waiters[j].priority]
+}
+
+func (h waiterHeap) Swap(i, j int) {
+	h.waiters[i], h.waiters[j] = h.waiters[j], h.waiters[i]
+	h.waiters[i].index = i
+	h.waiters[j].index = j
+}
+
+func (h *waiterHeap) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(h.waiters)
+	h.waiters = append(h.waiters, w)
+}
+
+func (h *waiterHeap) Pop() interface{} {
+	old := h.waiters
+	n := len(old)
+	w := old[n-1]
+	h.waiters = old[:n-1]
+	return w
+}
+
+//
+// Priority pool of workers
+// A fixed pool where a released worker is handed to the highest-priority waiting
+// caller instead of an arbitrary one, and per-priority quotas bound how many workers
+// a single class can hold concurrently
+//
+
+type priorityPool struct {
+	logger     logger.Logger
+	lock       sync.Mutex
+	idle       []*Worker
+	workers    []*Worker
+	statistics AllocatorStatistics
+
+	priorityRank map[string]int
+	quotas       map[string]int
+	held         map[string]int
+	heldBy       map[*Worker]string
+	waiters      waiterHeap
+}
+
+// NewPriorityPoolWorkerAllocator creates an allocator that hands released workers to
+// the highest-priority waiting caller. priorityOrder lists priorities from highest to
+// lowest; a priority not listed ranks lowest. quotas optionally caps the number of
+// workers a given priority may hold concurrently
+func NewPriorityPoolWorkerAllocator(parentLogger logger.Logger,
+	workers []*Worker,
+	priorityOrder []string,
+	quotas []PriorityQuota) (Allocator, error) {
+
+	priorityRank := map[string]int{}
+	for rank, priority := range priorityOrder {
+
+		// earlier entries in priorityOrder rank higher
+		priorityRank[priority] = len(priorityOrder) - rank
+	}
+
+	quotaByPriority := map[string]int{}
+	for _, quota := range quotas {
+		quotaByPriority[quota.Priority] = quota.MaxWorkers
+	}
+
+	newPriorityPool := &priorityPool{
+		logger:       parentLogger.GetChild("priority_pool_allocator"),
+		idle:         append([]*Worker{}, workers...),
+		workers:      workers,
+		priorityRank: priorityRank,
+		quotas:       quotaByPriority,
+		held:         map[string]int{},
+		heldBy:       map[*Worker]string{},
+		waiters:      waiterHeap{rank: priorityRank},
+	}
+
+	return newPriorityPool, nil
+}
+
+// Allocate allocates a worker with the default (lowest) priority
+func (pp *priorityPool) Allocate(timeout time.Duration) (*Worker, error) {
+	return pp.AllocateWithHint(timeout, "")
+}
+
+// AllocateWithHint allocates a worker, preferring to satisfy higher-priority callers
+// first when multiple are waiting for the same released worker
+func (pp *priorityPool) AllocateWithHint(timeout time.Duration, priority string) (*Worker, error) {
+	pp.statistics.WorkerAllocationCount++
+
+	workerInstance, ok := pp.tryAllocateLocked(priority)
+	if ok {
+		pp.statistics.WorkerAllocationSuccessImmediateTotal++
+		return workerInstance, nil
+	}
+
+	if timeout == 0 {
+		pp.statistics.WorkerAllocationTimeoutTotal++
+		return nil, ErrNoAvailableWorkers
+	}
+
+	waitStartAt := time.Now()
+	w := pp.enqueueWaiter(priority)
+
+	select {
+	case workerInstance := <-w.result:
+		pp.statistics.WorkerAllocationSuccessAfterWaitTotal++
+		pp.statistics.WorkerAllocationWaitDurationMilliSecondsSum += uint64(time.Since(waitStartAt).Nanoseconds() / 1e6)
+		return workerInstance, nil
+	case <-time.After(timeout):
+		if !pp.removeWaiter(w) {
+
+			// Release already popped this waiter (it raced the timeout) and is
+			// handing it a worker - take it off w.result rather than leaking it,
+			// and return it to the pool since this caller is no longer waiting
+			pp.Release(<-w.result)
+		}
+		pp.statistics.WorkerAllocationTimeoutTotal++
+		return nil, ErrNoAvailableWorkers
+	}
+}
+
+func (pp *priorityPool) tryAllocateLocked(priority string) (*Worker, bool) {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	if quota, hasQuota := pp.quotas[priority]; hasQuota && pp.held[priority] >= quota {
+		return nil, false
+	}
+
+	if len(pp.idle) == 0 {
+		return nil, false
+	}
+
+	workerInstance := pp.idle[0]
+	pp.idle = pp.idle[1:]
+	pp.held[priority]++
+	pp.heldBy[workerInstance] = priority
+
+	return workerInstance, true
+}
+
+func (pp *priorityPool) enqueueWaiter(priority string) *waiter {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	w := &waiter{priority: priority, result: make(chan *Worker, 1)}
+	heap.Push(&pp.waiters, w)
+
+	return w
+}
+
+// removeWaiter unqueues target, returning true if it was still queued. false means
+// Release already popped it (and is - or is about to be - sending it a worker on
+// target.result), racing the caller's timeout
+func (pp *priorityPool) removeWaiter(target *waiter) bool {
+	pp.lock.Lock()
+	defer pp.lock.Unlock()
+
+	if target.index >= 0 && target.index < len(pp.waiters.waiters) && pp.waiters.waiters[target.index] == target {
+		heap.Remove(&pp.waiters, target.index)
+		return true
+	}
+
+	return false
+}
+
+// Release hands the worker straight to the highest-priority waiting caller whose quota
+// still has room, skipping over (but not dropping) waiters that are already at quota,
+// or returns it to the idle pool if no waiter qualifies
+func (pp *priorityPool) Release(workerInstance *Worker) {
+	pp.lock.Lock()
+
+	if previousPriority, held := pp.heldBy[workerInstance]; held {
+		pp.held[previousPriority]--
+		delete(pp.heldBy, workerInstance)
+	}
+
+	var skipped []*waiter
+	for pp.waiters.Len() > 0 {
+		w := heap.Pop(&pp.waiters).(*waiter)
+
+		if quota, hasQuota := pp.quotas[w.priority]; hasQuota && pp.held[w.priority] >= quota {
+
+			// this waiter's class is still at quota - leave it waiting and try the next
+			skipped = append(skipped, w)
+			continue
+		}
+
+		for _, s := range skipped {
+			heap.Push(&pp.waiters, s)
+		}
+
+		pp.held[w.priority]++
+		pp.heldBy[workerInstance] = w.priority
+		pp.lock.Unlock()
+
+		w.result <- workerInstance
+		return
+	}
+
+	for _, s := range skipped {
+		heap.Push(&pp.waiters, s)
+	}
+
+	pp.idle = append(pp.idle, workerInstance)
+	pp.lock.Unlock()
+}
+
+// true if the several go routines can share this allocator
+func (pp *priorityPool) Shareable() bool {
+	return true
+}
+
+// get direct access to all workers for things like management / housekeeping
+func (pp *priorityPool) GetWorkers() []*Worker {
+	return pp.workers
+}
+
+// GetStatistics returns worker allocator statistics
+func (pp *priorityPool) GetStatistics() *AllocatorStatistics {
+	return &pp.statistics
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package worker
+
+import (
+	"testing"
+	"time"
+
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type PriorityPoolTestSuite struct {
+	suite.Suite
+}
+
+// TestReleaseHonorsQuotaOfPoppedWaiter reproduces the bug where Release handed a
+// worker to the highest-priority waiter without rechecking that waiter's quota: a
+// "high" priority waiter that is already at quota must be skipped in favor of a
+// lower-priority waiter that still has room, not handed another worker regardless
+func (suite *PriorityPoolTestSuite) TestReleaseHonorsQuotaOfPoppedWaiter() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	workerA := &Worker{}
+	workerB := &Worker{}
+
+	allocator, err := NewPriorityPoolWorkerAllocator(logger,
+		[]*Worker{workerA, workerB},
+		[]string{"high", "low"},
+		[]PriorityQuota{{Priority: "high", MaxWorkers: 1}})
+	suite.Require().NoError(err)
+
+	pp, ok := allocator.(*priorityPool)
+	suite.Require().True(ok)
+
+	hintAllocator, ok := allocator.(HintAllocator)
+	suite.Require().True(ok)
+
+	// "high" takes its one allowed worker, hitting quota
+	firstHigh, err := hintAllocator.AllocateWithHint(0, "high")
+	suite.Require().NoError(err)
+
+	// the pool's only other worker is taken by "low"
+	firstLow, err := hintAllocator.AllocateWithHint(0, "low")
+	suite.Require().NoError(err)
+
+	// a second "high" caller and a "low" caller both queue up waiting for a release
+	highWaiterResult := make(chan *Worker, 1)
+	go func() {
+		workerInstance, allocErr := hintAllocator.AllocateWithHint(time.Second, "high")
+		suite.Require().NoError(allocErr)
+		highWaiterResult <- workerInstance
+	}()
+
+	lowWaiterResult := make(chan *Worker, 1)
+	go func() {
+		workerInstance, allocErr := hintAllocator.AllocateWithHint(time.Second, "low")
+		suite.Require().NoError(allocErr)
+		lowWaiterResult <- workerInstance
+	}()
+
+	// wait for both waiters to be enqueued before releasing
+	suite.Require().Eventually(func() bool {
+		pp.lock.Lock()
+		defer pp.lock.Unlock()
+		return pp.waiters.Len() == 2
+	}, time.Second, time.Millisecond)
+
+	// release the "low" worker - "high" still holds firstHigh and is at quota (1), so
+	// its waiter must be skipped in favor of the "low" waiter, not handed this worker
+	allocator.Release(firstLow)
+
+	select {
+	case workerInstance := <-lowWaiterResult:
+		suite.Require().NotNil(workerInstance)
+	case <-time.After(time.Second):
+		suite.FailNow("low priority waiter was never serviced - quota was not rechecked on release")
+	}
+
+	select {
+	case <-highWaiterResult:
+		suite.FailNow("high priority waiter was serviced despite being at quota")
+	default:
+	}
+
+	pp.lock.Lock()
+	heldHigh := pp.held["high"]
+	pp.lock.Unlock()
+	suite.Require().Equal(1, heldHigh, "high priority class must never exceed its quota of 1")
+
+	// releasing firstHigh brings "high" under quota, so the parked "high" waiter is
+	// served now rather than timing out after this test has returned
+	allocator.Release(firstHigh)
+	<-highWaiterResult
+}
+
+// TestReleaseRacingTimeoutDoesNotLeakWorker reproduces the exact race AllocateWithHint
+// is exposed to: Release pops a waiter and sends it a worker, but that waiter's
+// timeout fires at the same moment, so its removeWaiter call finds nothing queued.
+// Before the fix, the worker sitting unread in that waiter's channel was simply
+// dropped - a real worker leaked from the pool on every occurrence under load
+func (suite *PriorityPoolTestSuite) TestReleaseRacingTimeoutDoesNotLeakWorker() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	worker := &Worker{}
+
+	allocator, err := NewPriorityPoolWorkerAllocator(logger, []*Worker{worker}, nil, nil)
+	suite.Require().NoError(err)
+
+	pp, ok := allocator.(*priorityPool)
+	suite.Require().True(ok)
+
+	// take the only worker, then enqueue a waiter exactly as AllocateWithHint does
+	// once it can't be satisfied immediately
+	held, err := allocator.Allocate(0)
+	suite.Require().NoError(err)
+
+	w := pp.enqueueWaiter("")
+
+	// Release pops w and hands it the worker - in the real race this happens in the
+	// narrow window right before the waiter's own timeout fires
+	allocator.Release(held)
+
+	// mirror AllocateWithHint's timeout branch: removeWaiter must report the waiter
+	// was already popped, at which point the fix recovers the worker from w.result
+	// instead of leaving it stranded there
+	suite.Require().False(pp.removeWaiter(w))
+	pp.Release(<-w.result)
+
+	// the worker must be back in the pool, not leaked
+	recovered, err := allocator.Allocate(0)
+	suite.Require().NoError(err)
+	suite.Require().Same(worker, recovered)
+}
+
+func TestPriorityPoolTestSuite(t *testing.T) {
+	suite.Run(t, new(PriorityPoolTestSuite))
+}
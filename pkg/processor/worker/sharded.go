@@ -0,0 +1,183 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package worker
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/nuclio/logger"
+)
+
+// ShardedAllocator is implemented by allocators that partition their workers into
+// shards. Callers that care about shard affinity should type-assert an Allocator to
+// this interface and fall back to plain Allocate when it isn't implemented,
+// preserving backward compatibility with allocators that have no notion of sharding
+type ShardedAllocator interface {
+
+	// AllocateFromShard allocates a worker, preferring the shard identified by
+	// shardKey and falling back to stealing from sibling shards
+	AllocateFromShard(timeout time.Duration, shardKey uint64) (*Worker, error)
+
+	// GetShardStatistics returns per-shard allocation, steal and cross-shard wait
+	// counters so operators can tune shard count
+	GetShardStatistics() []ShardStatistics
+}
+
+// ShardStatistics tracks per-shard allocation counters for the sharded allocator
+type ShardStatistics struct {
+	AllocationsTotal              uint64
+	StealsTotal                   uint64
+	CrossShardWaitMilliSecondsSum uint64
+}
+
+// shardedPool partitions workers into shards, each with its own workerChan, to avoid
+// the contention of a single shared channel under high fan-in. Allocate picks a shard
+// by hash and only scans sibling shards (work stealing) when its local shard is empty
+type shardedPool struct {
+	logger     logger.Logger
+	shards     []chan *Worker
+	workers    []*Worker
+	statistics AllocatorStatistics
+	shardStats []ShardStatistics
+}
+
+// NewShardedPoolWorkerAllocator creates an allocator that partitions workers into
+// numShards shards (defaulting to runtime.NumCPU() when numShards <= 0). Allocate picks
+// a shard via shardKey % numShards and falls back to scanning sibling shards,
+// stealing a worker from whichever one has one available, if its own shard is empty
+func NewShardedPoolWorkerAllocator(parentLogger logger.Logger,
+	workers []*Worker,
+	numShards int) (Allocator, error) {
+
+	if numShards <= 0 {
+		numShards = runtime.NumCPU()
+	}
+
+	shards := make([]chan *Worker, numShards)
+	for i := range shards {
+		shards[i] = make(chan *Worker, len(workers))
+	}
+
+	for i, workerInstance := range workers {
+		shard := i % numShards
+		shards[shard] <- workerInstance
+	}
+
+	return &shardedPool{
+		logger:     parentLogger.GetChild("sharded_pool_allocator"),
+		shards:     shards,
+		workers:    workers,
+		shardStats: make([]ShardStatistics, numShards),
+	}, nil
+}
+
+// Allocate allocates a worker using the default (shard 0) hash. Callers that care
+// about shard affinity should use AllocateFromShard
+func (sp *shardedPool) Allocate(timeout time.Duration) (*Worker, error) {
+	return sp.AllocateFromShard(timeout, 0)
+}
+
+// AllocateFromShard allocates a worker, preferring the shard identified by shardKey
+// (typically a goroutine id or event key hashed by the caller) and falling back to
+// stealing from sibling shards before waiting out the timeout
+func (sp *shardedPool) AllocateFromShard(timeout time.Duration, shardKey uint64) (*Worker, error) {
+	sp.statistics.WorkerAllocationCount++
+
+	numShards := len(sp.shards)
+	localShard := int(shardKey % uint64(numShards))
+
+	if workerInstance, ok := sp.tryShard(localShard); ok {
+		sp.statistics.WorkerAllocationSuccessImmediateTotal++
+		sp.shardStats[localShard].AllocationsTotal++
+		return workerInstance, nil
+	}
+
+	// local shard empty - attempt to steal from sibling shards
+	for offset := 1; offset < numShards; offset++ {
+		siblingShard := (localShard + offset) % numShards
+
+		if workerInstance, ok := sp.tryShard(siblingShard); ok {
+			sp.statistics.WorkerAllocationSuccessImmediateTotal++
+			sp.shardStats[siblingShard].StealsTotal++
+			return workerInstance, nil
+		}
+	}
+
+	if timeout == 0 {
+		sp.statistics.WorkerAllocationTimeoutTotal++
+		return nil, ErrNoAvailableWorkers
+	}
+
+	waitStartAt := time.Now()
+
+	select {
+	case workerInstance := <-sp.shards[localShard]:
+		sp.statistics.WorkerAllocationSuccessAfterWaitTotal++
+		sp.shardStats[localShard].CrossShardWaitMilliSecondsSum += uint64(time.Since(waitStartAt).Nanoseconds() / 1e6)
+		return workerInstance, nil
+	case <-time.After(timeout):
+		sp.statistics.WorkerAllocationTimeoutTotal++
+		return nil, ErrNoAvailableWorkers
+	}
+}
+
+func (sp *shardedPool) tryShard(shard int) (*Worker, bool) {
+	select {
+	case workerInstance := <-sp.shards[shard]:
+		return workerInstance, true
+	default:
+		return nil, false
+	}
+}
+
+// Release returns the worker to the shard it was originally assigned to, determined
+// by its position among the allocator's known workers
+func (sp *shardedPool) Release(workerInstance *Worker) {
+	numShards := len(sp.shards)
+
+	for i, candidate := range sp.workers {
+		if candidate == workerInstance {
+			sp.shards[i%numShards] <- workerInstance
+			return
+		}
+	}
+
+	// unknown worker - fall back to shard 0 rather than dropping it
+	sp.shards[0] <- workerInstance
+}
+
+// true if the several go routines can share this allocator
+func (sp *shardedPool) Shareable() bool {
+	return true
+}
+
+// get direct access to all workers for things like management / housekeeping
+func (sp *shardedPool) GetWorkers() []*Worker {
+	return sp.workers
+}
+
+// GetStatistics returns worker allocator statistics
+func (sp *shardedPool) GetStatistics() *AllocatorStatistics {
+	return &sp.statistics
+}
+
+// GetShardStatistics returns per-shard allocation, steal and cross-shard wait counters
+// so operators can tune shard count
+func (sp *shardedPool) GetShardStatistics() []ShardStatistics {
+	return sp.shardStats
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package worker
+
+import (
+	"testing"
+	"time"
+
+	nucliozap "github.com/nuclio/zap"
+	"github.com/stretchr/testify/suite"
+)
+
+type ShardedPoolTestSuite struct {
+	suite.Suite
+}
+
+// TestAllocateFromShardStealsFromSiblingWhenLocalEmpty drains shard 0, then allocates
+// with a shardKey that hashes to shard 0 again - the pool must steal from shard 1
+// rather than blocking, and record the steal in that shard's statistics
+func (suite *ShardedPoolTestSuite) TestAllocateFromShardStealsFromSiblingWhenLocalEmpty() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	workerA := &Worker{}
+	workerB := &Worker{}
+
+	allocator, err := NewShardedPoolWorkerAllocator(logger, []*Worker{workerA, workerB}, 2)
+	suite.Require().NoError(err)
+
+	shardedAllocator, ok := allocator.(ShardedAllocator)
+	suite.Require().True(ok, "NewShardedPoolWorkerAllocator must return a ShardedAllocator")
+
+	// drain shard 0 (workerA, assigned by index 0 % 2 == 0)
+	drained, err := shardedAllocator.AllocateFromShard(0, 0)
+	suite.Require().NoError(err)
+	suite.Require().Same(workerA, drained)
+
+	// shard 0 is now empty - this must steal workerB from shard 1 instead of timing out
+	stolen, err := shardedAllocator.AllocateFromShard(0, 0)
+	suite.Require().NoError(err)
+	suite.Require().Same(workerB, stolen)
+
+	stats := shardedAllocator.GetShardStatistics()
+	suite.Require().Len(stats, 2)
+	suite.Require().EqualValues(1, stats[1].StealsTotal)
+}
+
+// TestAllocateDefaultsToShardZero ensures the plain Allocate method (used by callers
+// with no shard affinity) keeps working identically to AllocateFromShard(timeout, 0)
+func (suite *ShardedPoolTestSuite) TestAllocateDefaultsToShardZero() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	workerA := &Worker{}
+
+	allocator, err := NewShardedPoolWorkerAllocator(logger, []*Worker{workerA}, 1)
+	suite.Require().NoError(err)
+
+	workerInstance, err := allocator.Allocate(0)
+	suite.Require().NoError(err)
+	suite.Require().Same(workerA, workerInstance)
+}
+
+// TestReleaseReturnsWorkerToItsOriginalShard ensures a released worker goes back to
+// the shard it was originally assigned to, not wherever it happens to be stolen to
+func (suite *ShardedPoolTestSuite) TestReleaseReturnsWorkerToItsOriginalShard() {
+	logger, err := nucliozap.NewNuclioZapTest("test")
+	suite.Require().NoError(err)
+
+	workerA := &Worker{}
+	workerB := &Worker{}
+
+	allocator, err := NewShardedPoolWorkerAllocator(logger, []*Worker{workerA, workerB}, 2)
+	suite.Require().NoError(err)
+
+	shardedAllocator, ok := allocator.(ShardedAllocator)
+	suite.Require().True(ok)
+
+	stolen, err := shardedAllocator.AllocateFromShard(0, 1)
+	suite.Require().NoError(err)
+	suite.Require().Same(workerB, stolen)
+
+	allocator.Release(stolen)
+
+	// workerB must be back in shard 1, reachable via its own shardKey, not shard 0
+	workerInstance, err := shardedAllocator.AllocateFromShard(time.Millisecond, 1)
+	suite.Require().NoError(err)
+	suite.Require().Same(workerB, workerInstance)
+}
+
+func TestShardedPoolTestSuite(t *testing.T) {
+	suite.Run(t, new(ShardedPoolTestSuite))
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2017 The Nuclio Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package worker
+
+// AllocatorStatistics holds counters tracking worker allocation behavior. Allocators
+// update their own subset of these fields; callers should not assume every field is
+// populated by every allocator implementation
+type AllocatorStatistics struct {
+	WorkerAllocationCount                      uint64
+	WorkerAllocationWorkersAvailableTotal       uint64
+	WorkerAllocationSuccessImmediateTotal       uint64
+	WorkerAllocationSuccessAfterWaitTotal       uint64
+	WorkerAllocationTimeoutTotal                uint64
+	WorkerAllocationWaitDurationMilliSecondsSum uint64
+
+	// WorkersSpawnedTotal and WorkersReapedTotal count workers created (the initial
+	// minWorkers included) and torn down by idle reaping (elastic allocator)
+	WorkersSpawnedTotal uint64
+	WorkersReapedTotal  uint64
+	WorkersLiveGauge    uint64
+}